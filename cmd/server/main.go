@@ -2,13 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/yourusername/dungeon-crawler/internal/db"
+	"github.com/yourusername/dungeon-crawler/internal/game"
+	"github.com/yourusername/dungeon-crawler/internal/generator"
 	"github.com/yourusername/dungeon-crawler/internal/mcp"
 )
 
@@ -30,7 +37,8 @@ func corsMiddleware(allowedOrigins []string) mux.MiddlewareFunc {
 			if allowed {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+mcp.SessionHeader+", "+mcp.MCPSessionHeader+", MCP-Protocol-Version")
+				w.Header().Set("Access-Control-Expose-Headers", mcp.SessionHeader+", "+mcp.MCPSessionHeader)
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
 
@@ -52,6 +60,13 @@ type Server struct {
 }
 
 func main() {
+	stdio := flag.Bool("stdio", false, "Serve MCP over stdin/stdout instead of HTTP")
+	sessionID := flag.String("session", "", "Session ID for stdio mode (reuse one to resume a saved game)")
+	flag.Parse()
+
+	// Logs always go to stderr so they never corrupt the stdio protocol stream
+	log.SetOutput(os.Stderr)
+
 	// Get database path from env or use default
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
@@ -65,8 +80,21 @@ func main() {
 	}
 	defer database.Close()
 
-	// Initialize MCP server
-	mcpServer := mcp.NewServer()
+	// Initialize MCP server, persisting games to the database
+	mcpServer := mcp.NewServer(database)
+
+	if *stdio {
+		log.Printf("Starting dungeon crawler MCP server on stdio")
+		log.Printf("Database: %s", dbPath)
+		if err := mcp.NewStdioTransport(mcpServer, *sessionID, os.Stdin, os.Stdout).Serve(); err != nil {
+			log.Fatalf("stdio transport failed: %v", err)
+		}
+		return
+	}
+
+	// Keep only recently used games in memory; the rest resume from the database
+	stopEviction := mcpServer.StartSessionEviction(mcp.SessionSweepInterval, mcp.SessionIdleTimeout)
+	defer stopEviction()
 
 	// Create server
 	server := &Server{
@@ -111,16 +139,22 @@ func (s *Server) setupRoutes() {
 	// Health check
 	s.router.HandleFunc("/health", s.handleHealth).Methods("GET", "OPTIONS")
 
-	// MCP endpoints
+	// MCP JSON-RPC endpoint (spec-compliant clients)
+	s.router.HandleFunc("/mcp", s.mcpServer.ServeJSONRPC).Methods("POST", "OPTIONS")
+	s.router.HandleFunc("/mcp", s.handleMCPStream).Methods("GET")
+	s.router.HandleFunc("/mcp", s.handleMCPDelete).Methods("DELETE")
+
+	// Legacy MCP endpoints (used by the Next.js frontend)
 	s.router.HandleFunc("/mcp/tools", s.handleListTools).Methods("GET", "OPTIONS")
 	s.router.HandleFunc("/mcp/call", s.handleCallTool).Methods("POST", "OPTIONS")
 
-	// REST API endpoints (for future web UI)
+	// REST API endpoints (dashboard)
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 	api.HandleFunc("/character", s.handleCreateCharacter).Methods("POST", "OPTIONS")
 	api.HandleFunc("/character/{id}", s.handleGetCharacter).Methods("GET", "OPTIONS")
 	api.HandleFunc("/dungeon", s.handleCreateDungeon).Methods("POST", "OPTIONS")
 	api.HandleFunc("/dungeon/{id}", s.handleGetDungeon).Methods("GET", "OPTIONS")
+	api.HandleFunc("/scores", s.handleGetScores).Methods("GET", "OPTIONS")
 
 	// Serve static files (future frontend)
 	// s.router.PathPrefix("/").Handler(http.FileServer(http.Dir("./static")))
@@ -159,64 +193,274 @@ func (s *Server) handleCallTool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.mcpServer.CallTool(req.Name, req.Arguments)
+	// The session ID comes from the header, falling back to the "session_id" argument
+	result, err := s.mcpServer.CallTool(r.Header.Get(mcp.SessionHeader), req.Name, req.Arguments)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if result.SessionID != "" {
+		w.Header().Set(mcp.SessionHeader, result.SessionID)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		log.Printf("Error encoding tool result: %v", err)
 	}
 }
 
-// REST API handlers (stubs)
+// sseKeepAlive is how often an idle event stream sends a comment line so
+// proxies don't close the connection
+const sseKeepAlive = 15 * time.Second
+
+// MCP server-push stream (streamable HTTP GET). Streams a JSON-RPC notification
+// for every game state change in the session as server-sent events. Browsers'
+// EventSource cannot set headers, so the session may also be passed as the
+// session_id query parameter.
+func (s *Server) handleMCPStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
 
-func (s *Server) handleCreateCharacter(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement character creation
+	sessionID := r.Header.Get(mcp.MCPSessionHeader)
+	if sessionID == "" {
+		sessionID = r.Header.Get(mcp.SessionHeader)
+	}
+	if sessionID == "" {
+		sessionID = r.URL.Query().Get("session_id")
+	}
+	if sessionID == "" {
+		http.Error(w, "missing session ID", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := s.mcpServer.Subscribe(sessionID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(mcp.MCPSessionHeader, sessionID)
+	w.WriteHeader(http.StatusOK)
+
+	// Start new subscribers in sync with the current state
+	if current := s.mcpServer.CurrentState(sessionID); current != nil {
+		if data, err := json.Marshal(current); err == nil {
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", event.ID, event.Data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// MCP session termination handler
+func (s *Server) handleMCPDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(mcp.MCPSessionHeader)
+	if sessionID == "" {
+		http.Error(w, "missing "+mcp.MCPSessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+	if !s.mcpServer.HasSession(sessionID) {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	s.mcpServer.CloseSession(sessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON encodes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message": "Character creation not yet implemented",
-	}); err != nil {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
 		log.Printf("Error encoding response: %v", err)
 	}
 }
 
+// writeError encodes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// REST API handlers
+
+// dungeonResponse is the REST representation of a dungeon layout
+type dungeonResponse struct {
+	Dungeon     *game.Dungeon          `json:"dungeon"`
+	Rooms       []*game.Room           `json:"rooms"`
+	Connections []*game.RoomConnection `json:"connections"`
+}
+
+func (s *Server) handleCreateCharacter(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	character := game.NewCharacter(req.Name)
+	if err := s.db.CreateCharacter(character); err != nil {
+		log.Printf("Error creating character: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to create character")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, character)
+}
+
 func (s *Server) handleGetCharacter(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement character retrieval
-	vars := mux.Vars(r)
-	characterID := vars["id"]
+	characterID := mux.Vars(r)["id"]
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message":      "Character retrieval not yet implemented",
-		"character_id": characterID,
-	}); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	character, err := s.db.GetCharacter(characterID)
+	if err != nil {
+		log.Printf("Error loading character %s: %v", characterID, err)
+		writeError(w, http.StatusInternalServerError, "failed to load character")
+		return
+	}
+	if character == nil {
+		writeError(w, http.StatusNotFound, "character not found")
+		return
 	}
+
+	writeJSON(w, http.StatusOK, character)
 }
 
 func (s *Server) handleCreateDungeon(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement dungeon generation
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message": "Dungeon generation not yet implemented",
-	}); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	var req struct {
+		Seed   *int64 `json:"seed"`
+		Depth  int    `json:"depth"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	// An empty body means a random seed at depth 1 and the default size
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	if req.Depth < 1 {
+		req.Depth = 1
+	}
+	if req.Width == 0 {
+		req.Width = generator.DefaultWidth
+	}
+	if req.Height == 0 {
+		req.Height = generator.DefaultHeight
+	}
+
+	gen := generator.NewLevelGenerator(seed, req.Depth).WithSize(req.Width, req.Height)
+	dungeon, rooms, connections, err := gen.GenerateDungeon(req.Depth)
+	if err != nil {
+		log.Printf("Error generating dungeon: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to generate dungeon")
+		return
 	}
+	if err := s.db.SaveDungeon(dungeon, rooms, connections); err != nil {
+		log.Printf("Error saving dungeon: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to save dungeon")
+		return
+	}
+
+	// Reload so rooms come back with their exits filled in
+	dungeon, rooms, connections, err = s.db.GetDungeonLayout(dungeon.ID)
+	if err != nil || dungeon == nil {
+		log.Printf("Error loading new dungeon: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to load dungeon")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, &dungeonResponse{
+		Dungeon:     dungeon,
+		Rooms:       rooms,
+		Connections: connections,
+	})
 }
 
 func (s *Server) handleGetDungeon(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement dungeon retrieval
-	vars := mux.Vars(r)
-	dungeonID := vars["id"]
+	dungeonID := mux.Vars(r)["id"]
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message":    "Dungeon retrieval not yet implemented",
-		"dungeon_id": dungeonID,
-	}); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	dungeon, rooms, connections, err := s.db.GetDungeonLayout(dungeonID)
+	if err != nil {
+		log.Printf("Error loading dungeon %s: %v", dungeonID, err)
+		writeError(w, http.StatusInternalServerError, "failed to load dungeon")
+		return
+	}
+	if dungeon == nil {
+		writeError(w, http.StatusNotFound, "dungeon not found")
+		return
 	}
+
+	writeJSON(w, http.StatusOK, &dungeonResponse{
+		Dungeon:     dungeon,
+		Rooms:       rooms,
+		Connections: connections,
+	})
+}
+
+// defaultScoreLimit and maxScoreLimit bound the score history returned
+const (
+	defaultScoreLimit = 10
+	maxScoreLimit     = 100
+)
+
+func (s *Server) handleGetScores(w http.ResponseWriter, r *http.Request) {
+	limit := defaultScoreLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		if n > maxScoreLimit {
+			n = maxScoreLimit
+		}
+		limit = n
+	}
+
+	scores, err := s.db.GetHighScores(limit)
+	if err != nil {
+		log.Printf("Error loading scores: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to load scores")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"scores": scores,
+	})
 }
@@ -23,6 +23,10 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// SQLite allows a single writer; serialize access through one connection
+	// (this also keeps the foreign_keys pragma below applied to every query)
+	conn.SetMaxOpenConns(1)
+
 	// Enable foreign keys
 	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
@@ -49,9 +53,97 @@ func (db *DB) initSchema() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	return db.migrateColumns()
+}
+
+// columnMigrations lists columns added to tables after they were first created.
+// CREATE TABLE IF NOT EXISTS leaves existing databases untouched, so these are
+// added with ALTER TABLE when missing.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"items", "rarity", "TEXT DEFAULT 'common'"},
+	{"game_sessions", "seed", "INTEGER"},
+	{"game_sessions", "id_prefix", "TEXT"},
+	{"game_sessions", "dice_draws", "INTEGER DEFAULT 0"},
+	{"game_sessions", "action_log", "TEXT"},
+	{"traps", "name", "TEXT"},
+	{"room_connections", "is_locked", "BOOLEAN DEFAULT 0"},
+	{"room_connections", "key_id", "TEXT"},
+	{"characters", "gold", "INTEGER DEFAULT 0"},
+	{"items", "value", "INTEGER DEFAULT 0"},
+	{"game_sessions", "turn_number", "INTEGER DEFAULT 0"},
+	{"game_sessions", "previous_room_id", "TEXT"},
+	{"game_sessions", "final_depth", "INTEGER"},
+	{"game_sessions", "kills", "INTEGER"},
+	{"dungeons", "width", "INTEGER DEFAULT 5"},
+	{"dungeons", "height", "INTEGER DEFAULT 5"},
+	{"characters", "level", "INTEGER DEFAULT 1"},
+	{"characters", "xp", "INTEGER DEFAULT 0"},
+	{"characters", "stat_points", "INTEGER DEFAULT 0"},
+	{"characters", "class", "TEXT DEFAULT 'adventurer'"},
+	{"characters", "effects", "TEXT"},
+	{"monsters", "on_hit", "TEXT"},
+	{"monsters", "effects", "TEXT"},
+	{"items", "effect", "TEXT"},
+	{"items", "cures", "TEXT"},
+	{"items", "on_hit", "TEXT"},
+	{"characters", "mana", "INTEGER DEFAULT 0"},
+	{"characters", "max_mana", "INTEGER"},
+	{"characters", "shield", "INTEGER DEFAULT 0"},
+	{"game_sessions", "revealed_rooms", "TEXT"},
+	{"game_sessions", "gold_collected", "INTEGER"},
+	{"game_sessions", "rooms_visited", "INTEGER"},
+	{"game_sessions", "rooms_generated", "INTEGER"},
+}
+
+// migrateColumns adds any missing columns from columnMigrations
+func (db *DB) migrateColumns() error {
+	for _, m := range columnMigrations {
+		exists, err := db.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
 	return nil
 }
 
+// columnExists checks whether a table has the given column
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
@@ -0,0 +1,771 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// SaveGame writes the full game state for a session, replacing any earlier save
+func (db *DB) SaveGame(sessionID string, gs *game.GameState) error {
+	if gs.Character == nil || gs.Dungeon == nil {
+		return fmt.Errorf("cannot save an uninitialized game")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteSession(tx, sessionID); err != nil {
+		return err
+	}
+
+	if err := insertCharacter(tx, gs.Character); err != nil {
+		return err
+	}
+	if err := insertDungeon(tx, gs.Dungeon); err != nil {
+		return err
+	}
+	for _, room := range gs.Rooms {
+		if err := insertRoom(tx, room); err != nil {
+			return err
+		}
+	}
+	for _, conns := range gs.Connections {
+		for _, conn := range conns {
+			if err := insertConnection(tx, conn); err != nil {
+				return err
+			}
+		}
+	}
+	for _, m := range gs.Monsters {
+		if err := insertMonster(tx, m); err != nil {
+			return err
+		}
+	}
+	for _, item := range gs.Items {
+		if err := insertItem(tx, item); err != nil {
+			return err
+		}
+	}
+	for _, trap := range gs.Traps {
+		if err := insertTrap(tx, trap); err != nil {
+			return err
+		}
+	}
+	for _, merchant := range gs.Merchants {
+		if err := insertMerchant(tx, merchant); err != nil {
+			return err
+		}
+	}
+
+	visited := make([]string, 0, len(gs.VisitedRooms))
+	for roomID, ok := range gs.VisitedRooms {
+		if ok {
+			visited = append(visited, roomID)
+		}
+	}
+	visitedJSON, err := json.Marshal(visited)
+	if err != nil {
+		return fmt.Errorf("failed to encode visited rooms: %w", err)
+	}
+	revealed := make([]string, 0, len(gs.RevealedRooms))
+	for roomID, ok := range gs.RevealedRooms {
+		if ok {
+			revealed = append(revealed, roomID)
+		}
+	}
+	revealedJSON, err := json.Marshal(revealed)
+	if err != nil {
+		return fmt.Errorf("failed to encode revealed rooms: %w", err)
+	}
+
+	turnsInRoom, consecutiveCombat := 0, 0
+	if gs.TurnContext != nil {
+		turnsInRoom = gs.TurnContext.TurnsInRoom
+		consecutiveCombat = gs.TurnContext.ConsecutiveCombat
+	}
+
+	// The seed, draw count and action log let a resumed game keep rolling the
+	// same dice and still export a faithful replay
+	var seed, draws int64
+	if gs.Dice != nil {
+		seed, draws = gs.Dice.Seed(), int64(gs.Dice.Draws())
+	}
+	actionsJSON, err := json.Marshal(gs.ActionLog)
+	if err != nil {
+		return fmt.Errorf("failed to encode action log: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO game_sessions
+		(id, character_id, dungeon_id, game_over, victory, visited_rooms, turns_in_room, consecutive_combat,
+		seed, id_prefix, dice_draws, action_log, turn_number, previous_room_id, final_depth, kills, revealed_rooms,
+		gold_collected, rooms_visited, rooms_generated, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sessionID, gs.Character.ID, gs.Dungeon.ID, gs.GameOver, gs.Victory, string(visitedJSON),
+		turnsInRoom, consecutiveCombat, seed, gs.IDPrefix, draws, string(actionsJSON), gs.TurnNumber, gs.PreviousRoomID,
+		gs.FinalDepth, gs.Kills, string(revealedJSON), gs.GoldCollected, gs.RoomsVisited, gs.RoomsGenerated,
+		time.Now()); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	// Finished runs go into the score history, which outlives the session
+	if gs.Score != nil {
+		if err := insertScore(tx, sessionID, gs); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit game save: %w", err)
+	}
+	return nil
+}
+
+// LoadGame rebuilds the game state saved for a session, including the room,
+// character and visited-room indexes. It returns nil if nothing was saved.
+func (db *DB) LoadGame(sessionID string) (*game.GameState, error) {
+	var (
+		characterID, dungeonID  string
+		gameOver, victory       bool
+		visitedJSON             sql.NullString
+		revealedJSON            sql.NullString
+		turnsInRoom, combat     int
+		seed, draws, turnNumber sql.NullInt64
+		finalDepth, kills       sql.NullInt64
+		goldCollected           sql.NullInt64
+		roomsVisited, generated sql.NullInt64
+		idPrefix, actionsJSON   sql.NullString
+		previousRoomID          sql.NullString
+	)
+	err := db.conn.QueryRow(`SELECT character_id, dungeon_id, game_over, victory, visited_rooms,
+		turns_in_room, consecutive_combat, seed, id_prefix, dice_draws, action_log, turn_number,
+		previous_room_id, final_depth, kills, revealed_rooms, gold_collected, rooms_visited, rooms_generated
+		FROM game_sessions WHERE id = ?`, sessionID).
+		Scan(&characterID, &dungeonID, &gameOver, &victory, &visitedJSON, &turnsInRoom, &combat,
+			&seed, &idPrefix, &draws, &actionsJSON, &turnNumber, &previousRoomID, &finalDepth, &kills, &revealedJSON,
+			&goldCollected, &roomsVisited, &generated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	gs := game.NewGameState()
+	gs.GameOver = gameOver
+	gs.Victory = victory
+	gs.TurnContext.TurnsInRoom = turnsInRoom
+	gs.TurnContext.ConsecutiveCombat = combat
+	gs.TurnNumber = int(turnNumber.Int64)
+	gs.PreviousRoomID = previousRoomID.String
+	gs.IDPrefix = idPrefix.String
+	if seed.Valid {
+		gs.Dice = game.RestoreDice(seed.Int64, uint64(draws.Int64))
+	} else {
+		// Saved before seeds were recorded; the game can't be replayed but
+		// still needs dice to continue
+		gs.Dice = game.NewDice(time.Now().UnixNano())
+	}
+	if actionsJSON.Valid && actionsJSON.String != "" {
+		if err := json.Unmarshal([]byte(actionsJSON.String), &gs.ActionLog); err != nil {
+			return nil, fmt.Errorf("failed to decode action log: %w", err)
+		}
+	}
+
+	if gs.Character, err = db.GetCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if gs.Dungeon, err = db.GetDungeon(dungeonID); err != nil {
+		return nil, err
+	}
+	if gs.Character == nil || gs.Dungeon == nil {
+		return nil, fmt.Errorf("saved game for session %s is incomplete", sessionID)
+	}
+
+	// Saves from before multi-level runs end at the level they were on
+	gs.FinalDepth = gs.Dungeon.Depth
+	if finalDepth.Valid {
+		gs.FinalDepth = int(finalDepth.Int64)
+	}
+
+	rooms, err := db.getRooms(dungeonID)
+	if err != nil {
+		return nil, err
+	}
+	for _, room := range rooms {
+		gs.AddRoom(room)
+	}
+
+	conns, err := db.getConnections(dungeonID)
+	if err != nil {
+		return nil, err
+	}
+	for _, conn := range conns {
+		gs.AddConnection(conn)
+	}
+
+	if err := db.loadMonsters(gs, dungeonID); err != nil {
+		return nil, err
+	}
+	if err := db.loadItems(gs, dungeonID, characterID); err != nil {
+		return nil, err
+	}
+	if err := db.loadTraps(gs, dungeonID); err != nil {
+		return nil, err
+	}
+	if err := db.loadMerchants(gs, dungeonID); err != nil {
+		return nil, err
+	}
+
+	// Saves from before kills were counted only have this level's dead monsters
+	if kills.Valid {
+		gs.Kills = int(kills.Int64)
+	} else {
+		for _, m := range gs.Monsters {
+			if !m.IsAlive {
+				gs.Kills++
+			}
+		}
+	}
+
+	// Saves from before gold collected was counted only have the gold on hand
+	if goldCollected.Valid {
+		gs.GoldCollected = int(goldCollected.Int64)
+	} else {
+		gs.GoldCollected = gs.Character.Gold
+	}
+
+	if gs.GameOver {
+		if gs.Score, err = db.getScore(characterID); err != nil {
+			return nil, err
+		}
+	}
+
+	if visitedJSON.Valid && visitedJSON.String != "" {
+		var visited []string
+		if err := json.Unmarshal([]byte(visitedJSON.String), &visited); err != nil {
+			return nil, fmt.Errorf("failed to decode visited rooms: %w", err)
+		}
+		for _, roomID := range visited {
+			gs.MarkRoomVisited(roomID)
+		}
+	}
+
+	// Saves from before rooms were counted across levels only have this level's
+	// rooms, which MarkRoomVisited above has already counted as visited
+	if roomsVisited.Valid {
+		gs.RoomsVisited = int(roomsVisited.Int64)
+	}
+	gs.RoomsGenerated = len(gs.Rooms)
+	if generated.Valid {
+		gs.RoomsGenerated = int(generated.Int64)
+	}
+
+	var revealed []string
+	if err := decodeJSONColumn(revealedJSON, &revealed); err != nil {
+		return nil, fmt.Errorf("failed to decode revealed rooms: %w", err)
+	}
+	for _, roomID := range revealed {
+		gs.RevealedRooms[roomID] = true
+	}
+
+	return gs, nil
+}
+
+// DeleteGame removes a session's saved game
+func (db *DB) DeleteGame(sessionID string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteSession(tx, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteSession removes a session and every row belonging to its character and dungeon
+func deleteSession(tx *sql.Tx, sessionID string) error {
+	var characterID, dungeonID string
+	err := tx.QueryRow(`SELECT character_id, dungeon_id FROM game_sessions WHERE id = ?`, sessionID).
+		Scan(&characterID, &dungeonID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	// Children first so foreign keys stay satisfied
+	stmts := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`DELETE FROM game_sessions WHERE id = ?`, []interface{}{sessionID}},
+		{`DELETE FROM items WHERE character_id = ? OR room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, []interface{}{characterID, dungeonID}},
+		{`DELETE FROM monsters WHERE room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, []interface{}{dungeonID}},
+		{`DELETE FROM traps WHERE room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, []interface{}{dungeonID}},
+		{`DELETE FROM merchants WHERE room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, []interface{}{dungeonID}},
+		{`DELETE FROM room_connections WHERE room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, []interface{}{dungeonID}},
+		{`DELETE FROM rooms WHERE dungeon_id = ?`, []interface{}{dungeonID}},
+		{`DELETE FROM dungeons WHERE id = ?`, []interface{}{dungeonID}},
+		{`DELETE FROM characters WHERE id = ?`, []interface{}{characterID}},
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("failed to clear saved game: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertCharacter writes a character row
+func insertCharacter(ex execer, c *game.Character) error {
+	effectsJSON, err := json.Marshal(c.Effects)
+	if err != nil {
+		return fmt.Errorf("failed to encode status effects: %w", err)
+	}
+	_, err = ex.Exec(`INSERT INTO characters
+		(id, name, hp, max_hp, strength, dexterity, current_room_id, is_alive, created_at, died_at, gold,
+		level, xp, stat_points, class, effects, mana, max_mana, shield)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.Name, c.HP, c.MaxHP, c.Strength, c.Dexterity, c.CurrentRoomID, c.IsAlive, c.CreatedAt, c.DiedAt, c.Gold,
+		c.Level, c.XP, c.StatPoints, c.Class, string(effectsJSON), c.Mana, c.MaxMana, c.Shield)
+	if err != nil {
+		return fmt.Errorf("failed to save character: %w", err)
+	}
+	return nil
+}
+
+// insertDungeon writes a dungeon row
+func insertDungeon(ex execer, d *game.Dungeon) error {
+	_, err := ex.Exec(`INSERT INTO dungeons (id, seed, depth, width, height, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.ID, d.Seed, d.Depth, d.Width, d.Height, d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save dungeon: %w", err)
+	}
+	return nil
+}
+
+// insertRoom writes a room row
+func insertRoom(ex execer, r *game.Room) error {
+	_, err := ex.Exec(`INSERT INTO rooms (id, dungeon_id, name, description, is_entrance, is_exit, x, y)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.DungeonID, r.Name, r.Description, r.IsEntrance, r.IsExit, r.X, r.Y)
+	if err != nil {
+		return fmt.Errorf("failed to save room: %w", err)
+	}
+	return nil
+}
+
+// insertConnection writes a room connection row
+func insertConnection(ex execer, c *game.RoomConnection) error {
+	_, err := ex.Exec(`INSERT INTO room_connections (id, room_id, direction, connected_room_id, is_locked, key_id)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		c.ID, c.RoomID, c.Direction, c.ConnectedRoomID, c.IsLocked, c.KeyID)
+	if err != nil {
+		return fmt.Errorf("failed to save room connection: %w", err)
+	}
+	return nil
+}
+
+// insertMonster writes a monster row
+func insertMonster(ex execer, m *game.Monster) error {
+	lootJSON, err := json.Marshal(m.LootTable)
+	if err != nil {
+		return fmt.Errorf("failed to encode loot table: %w", err)
+	}
+	onHitJSON, err := json.Marshal(m.OnHit)
+	if err != nil {
+		return fmt.Errorf("failed to encode on-hit effect: %w", err)
+	}
+	effectsJSON, err := json.Marshal(m.Effects)
+	if err != nil {
+		return fmt.Errorf("failed to encode status effects: %w", err)
+	}
+	_, err = ex.Exec(`INSERT INTO monsters (id, name, description, hp, max_hp, damage, room_id, is_alive, loot_table,
+		on_hit, effects)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ID, m.Name, m.Description, m.HP, m.MaxHP, m.Damage, m.RoomID, m.IsAlive, string(lootJSON),
+		string(onHitJSON), string(effectsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save monster: %w", err)
+	}
+	return nil
+}
+
+// insertItem writes an item row
+func insertItem(ex execer, item *game.Item) error {
+	effectJSON, err := json.Marshal(item.Effect)
+	if err != nil {
+		return fmt.Errorf("failed to encode item effect: %w", err)
+	}
+	onHitJSON, err := json.Marshal(item.OnHit)
+	if err != nil {
+		return fmt.Errorf("failed to encode item on-hit effect: %w", err)
+	}
+	_, err = ex.Exec(`INSERT INTO items
+		(id, name, description, type, damage, armor, healing, rarity, value, room_id, character_id, is_equipped,
+		effect, cures, on_hit)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ID, item.Name, item.Description, item.Type, item.Damage, item.Armor, item.Healing,
+		item.Rarity, item.Value, item.RoomID, item.CharacterID, item.IsEquipped, string(effectJSON), item.Cures,
+		string(onHitJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	return nil
+}
+
+// insertTrap writes a trap row
+func insertTrap(ex execer, t *game.Trap) error {
+	_, err := ex.Exec(`INSERT INTO traps (id, room_id, name, description, damage, is_triggered, is_discovered, difficulty)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.RoomID, t.Name, t.Description, t.Damage, t.IsTriggered, t.IsDiscovered, t.Difficulty)
+	if err != nil {
+		return fmt.Errorf("failed to save trap: %w", err)
+	}
+	return nil
+}
+
+// insertMerchant writes a merchant row
+func insertMerchant(ex execer, m *game.Merchant) error {
+	stockJSON, err := json.Marshal(m.Stock)
+	if err != nil {
+		return fmt.Errorf("failed to encode merchant stock: %w", err)
+	}
+	_, err = ex.Exec(`INSERT INTO merchants (id, room_id, name, description, stock) VALUES (?, ?, ?, ?, ?)`,
+		m.ID, m.RoomID, m.Name, m.Description, string(stockJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save merchant: %w", err)
+	}
+	return nil
+}
+
+// CreateCharacter stores a new character
+func (db *DB) CreateCharacter(c *game.Character) error {
+	return insertCharacter(db.conn, c)
+}
+
+// SaveDungeon stores a generated dungeon along with its rooms and connections
+func (db *DB) SaveDungeon(d *game.Dungeon, rooms []*game.Room, conns []*game.RoomConnection) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertDungeon(tx, d); err != nil {
+		return err
+	}
+	for _, room := range rooms {
+		if err := insertRoom(tx, room); err != nil {
+			return err
+		}
+	}
+	for _, conn := range conns {
+		if err := insertConnection(tx, conn); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit dungeon: %w", err)
+	}
+	return nil
+}
+
+// GetDungeonLayout loads a dungeon with its rooms and connections, filling in
+// each room's Exits. It returns a nil dungeon if none exists.
+func (db *DB) GetDungeonLayout(id string) (*game.Dungeon, []*game.Room, []*game.RoomConnection, error) {
+	dungeon, err := db.GetDungeon(id)
+	if err != nil || dungeon == nil {
+		return nil, nil, nil, err
+	}
+
+	rooms, err := db.getRooms(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conns, err := db.getConnections(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	roomsByID := make(map[string]*game.Room, len(rooms))
+	for _, room := range rooms {
+		room.Exits = make([]string, 0)
+		roomsByID[room.ID] = room
+	}
+	for _, conn := range conns {
+		if room := roomsByID[conn.RoomID]; room != nil {
+			room.Exits = append(room.Exits, conn.Direction)
+		}
+	}
+
+	return dungeon, rooms, conns, nil
+}
+
+// GetCharacter loads a character by ID. It returns nil if none exists.
+func (db *DB) GetCharacter(id string) (*game.Character, error) {
+	c := &game.Character{}
+	var (
+		currentRoomID sql.NullString
+		diedAt        sql.NullTime
+		class         sql.NullString
+		effectsJSON   sql.NullString
+	)
+	var gold, level, xp, statPoints, mana, maxMana, shield sql.NullInt64
+	err := db.conn.QueryRow(`SELECT id, name, hp, max_hp, strength, dexterity, current_room_id,
+		is_alive, created_at, died_at, gold, level, xp, stat_points, class, effects, mana, max_mana, shield
+		FROM characters WHERE id = ?`, id).
+		Scan(&c.ID, &c.Name, &c.HP, &c.MaxHP, &c.Strength, &c.Dexterity, &currentRoomID,
+			&c.IsAlive, &c.CreatedAt, &diedAt, &gold, &level, &xp, &statPoints, &class, &effectsJSON,
+			&mana, &maxMana, &shield)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load character: %w", err)
+	}
+	c.CurrentRoomID = currentRoomID.String
+	c.Gold = int(gold.Int64)
+	c.Level = int(level.Int64)
+	if c.Level < 1 {
+		c.Level = 1
+	}
+	c.XP = int(xp.Int64)
+	c.StatPoints = int(statPoints.Int64)
+	c.Class = class.String
+	if c.Class == "" {
+		c.Class = game.DefaultClass
+	}
+	c.Mana = int(mana.Int64)
+	c.MaxMana = int(maxMana.Int64)
+	c.Shield = int(shield.Int64)
+	// Characters saved before mana existed start with their class's full pool
+	if !maxMana.Valid {
+		if class, ok := game.Classes[c.Class]; ok {
+			c.MaxMana = class.MaxMana
+			c.Mana = class.MaxMana
+		}
+	}
+	if err := decodeJSONColumn(effectsJSON, &c.Effects); err != nil {
+		return nil, fmt.Errorf("failed to decode status effects: %w", err)
+	}
+	if diedAt.Valid {
+		c.DiedAt = &diedAt.Time
+	}
+	return c, nil
+}
+
+// GetDungeon loads a dungeon by ID. It returns nil if none exists.
+func (db *DB) GetDungeon(id string) (*game.Dungeon, error) {
+	d := &game.Dungeon{}
+	err := db.conn.QueryRow(`SELECT id, seed, depth, width, height, created_at FROM dungeons WHERE id = ?`, id).
+		Scan(&d.ID, &d.Seed, &d.Depth, &d.Width, &d.Height, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dungeon: %w", err)
+	}
+	return d, nil
+}
+
+// getRooms loads all rooms in a dungeon
+func (db *DB) getRooms(dungeonID string) ([]*game.Room, error) {
+	rows, err := db.conn.Query(`SELECT id, dungeon_id, name, description, is_entrance, is_exit, x, y
+		FROM rooms WHERE dungeon_id = ?`, dungeonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rooms: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := make([]*game.Room, 0)
+	for rows.Next() {
+		r := &game.Room{}
+		var description sql.NullString
+		if err := rows.Scan(&r.ID, &r.DungeonID, &r.Name, &description, &r.IsEntrance, &r.IsExit, &r.X, &r.Y); err != nil {
+			return nil, fmt.Errorf("failed to load room: %w", err)
+		}
+		r.Description = description.String
+		rooms = append(rooms, r)
+	}
+	return rooms, rows.Err()
+}
+
+// getConnections loads all room connections in a dungeon
+func (db *DB) getConnections(dungeonID string) ([]*game.RoomConnection, error) {
+	rows, err := db.conn.Query(`SELECT c.id, c.room_id, c.direction, c.connected_room_id, c.is_locked, c.key_id
+		FROM room_connections c JOIN rooms r ON r.id = c.room_id WHERE r.dungeon_id = ?`, dungeonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load room connections: %w", err)
+	}
+	defer rows.Close()
+
+	conns := make([]*game.RoomConnection, 0)
+	for rows.Next() {
+		c := &game.RoomConnection{}
+		var isLocked sql.NullBool
+		var keyID sql.NullString
+		if err := rows.Scan(&c.ID, &c.RoomID, &c.Direction, &c.ConnectedRoomID, &isLocked, &keyID); err != nil {
+			return nil, fmt.Errorf("failed to load room connection: %w", err)
+		}
+		c.IsLocked = isLocked.Bool
+		c.KeyID = keyID.String
+		conns = append(conns, c)
+	}
+	return conns, rows.Err()
+}
+
+// loadMonsters loads a dungeon's monsters into the game state
+func (db *DB) loadMonsters(gs *game.GameState, dungeonID string) error {
+	rows, err := db.conn.Query(`SELECT m.id, m.name, m.description, m.hp, m.max_hp, m.damage, m.room_id,
+		m.is_alive, m.loot_table, m.on_hit, m.effects FROM monsters m JOIN rooms r ON r.id = m.room_id WHERE r.dungeon_id = ?`, dungeonID)
+	if err != nil {
+		return fmt.Errorf("failed to load monsters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := &game.Monster{}
+		var description, lootJSON, onHitJSON, effectsJSON sql.NullString
+		if err := rows.Scan(&m.ID, &m.Name, &description, &m.HP, &m.MaxHP, &m.Damage, &m.RoomID,
+			&m.IsAlive, &lootJSON, &onHitJSON, &effectsJSON); err != nil {
+			return fmt.Errorf("failed to load monster: %w", err)
+		}
+		m.Description = description.String
+		if lootJSON.Valid && lootJSON.String != "" {
+			if err := json.Unmarshal([]byte(lootJSON.String), &m.LootTable); err != nil {
+				return fmt.Errorf("failed to decode loot table: %w", err)
+			}
+		}
+		if err := decodeJSONColumn(onHitJSON, &m.OnHit); err != nil {
+			return fmt.Errorf("failed to decode on-hit effect: %w", err)
+		}
+		if err := decodeJSONColumn(effectsJSON, &m.Effects); err != nil {
+			return fmt.Errorf("failed to decode status effects: %w", err)
+		}
+		gs.AddMonster(m)
+	}
+	return rows.Err()
+}
+
+// loadItems loads the items lying in a dungeon's rooms and carried by the character.
+// Equipped weapon and armor are restored on the character from the is_equipped flag.
+func (db *DB) loadItems(gs *game.GameState, dungeonID, characterID string) error {
+	rows, err := db.conn.Query(`SELECT id, name, description, type, damage, armor, healing, rarity, value,
+		room_id, character_id, is_equipped, effect, cures, on_hit FROM items
+		WHERE character_id = ? OR room_id IN (SELECT id FROM rooms WHERE dungeon_id = ?)`, characterID, dungeonID)
+	if err != nil {
+		return fmt.Errorf("failed to load items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &game.Item{}
+		var description, rarity, roomID, charID, effectJSON, cures, onHitJSON sql.NullString
+		var value sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.Name, &description, &item.Type, &item.Damage, &item.Armor,
+			&item.Healing, &rarity, &value, &roomID, &charID, &item.IsEquipped, &effectJSON, &cures, &onHitJSON); err != nil {
+			return fmt.Errorf("failed to load item: %w", err)
+		}
+		if err := decodeJSONColumn(effectJSON, &item.Effect); err != nil {
+			return fmt.Errorf("failed to decode item effect: %w", err)
+		}
+		if err := decodeJSONColumn(onHitJSON, &item.OnHit); err != nil {
+			return fmt.Errorf("failed to decode item on-hit effect: %w", err)
+		}
+		item.Cures = cures.String
+		item.Description = description.String
+		item.Rarity = rarity.String
+		item.Value = int(value.Int64)
+		if roomID.Valid {
+			item.RoomID = &roomID.String
+		}
+		if charID.Valid {
+			item.CharacterID = &charID.String
+		}
+		gs.AddItem(item)
+
+		if item.IsEquipped && gs.Character != nil {
+			switch item.Type {
+			case "weapon":
+				gs.Character.EquippedWeaponID = &item.ID
+			case "armor":
+				gs.Character.EquippedArmorID = &item.ID
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// loadTraps loads a dungeon's traps into the game state
+func (db *DB) loadTraps(gs *game.GameState, dungeonID string) error {
+	rows, err := db.conn.Query(`SELECT t.id, t.room_id, t.name, t.description, t.damage, t.is_triggered,
+		t.is_discovered, t.difficulty FROM traps t JOIN rooms r ON r.id = t.room_id WHERE r.dungeon_id = ?`, dungeonID)
+	if err != nil {
+		return fmt.Errorf("failed to load traps: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &game.Trap{}
+		var name, description sql.NullString
+		if err := rows.Scan(&t.ID, &t.RoomID, &name, &description, &t.Damage, &t.IsTriggered,
+			&t.IsDiscovered, &t.Difficulty); err != nil {
+			return fmt.Errorf("failed to load trap: %w", err)
+		}
+		t.Name = name.String
+		t.Description = description.String
+		gs.AddTrap(t)
+	}
+	return rows.Err()
+}
+
+// loadMerchants loads a dungeon's merchants and their stock into the game state
+func (db *DB) loadMerchants(gs *game.GameState, dungeonID string) error {
+	rows, err := db.conn.Query(`SELECT m.id, m.room_id, m.name, m.description, m.stock
+		FROM merchants m JOIN rooms r ON r.id = m.room_id WHERE r.dungeon_id = ?`, dungeonID)
+	if err != nil {
+		return fmt.Errorf("failed to load merchants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := &game.Merchant{}
+		var description, stockJSON sql.NullString
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.Name, &description, &stockJSON); err != nil {
+			return fmt.Errorf("failed to load merchant: %w", err)
+		}
+		m.Description = description.String
+		if err := decodeJSONColumn(stockJSON, &m.Stock); err != nil {
+			return fmt.Errorf("failed to decode merchant stock: %w", err)
+		}
+		gs.AddMerchant(m)
+	}
+	return rows.Err()
+}
+
+// decodeJSONColumn decodes a nullable JSON column into v, leaving v untouched
+// if the column is empty
+func decodeJSONColumn(col sql.NullString, v interface{}) error {
+	if !col.Valid || col.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(col.String), v)
+}
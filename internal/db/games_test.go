@@ -0,0 +1,263 @@
+package db
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+	"github.com/yourusername/dungeon-crawler/internal/generator"
+)
+
+// newTestDB opens a fresh database in a temporary directory
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	store, err := New(filepath.Join(t.TempDir(), "games.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// newGameInProgress builds a seeded warrior game the way new_game does, then
+// plays it forward a little so every saved column has something to hold
+func newGameInProgress(t *testing.T) *game.GameState {
+	t.Helper()
+	const seed = 42
+
+	gs := game.NewGameState()
+	gs.Dice = game.NewDice(seed)
+	gs.IDPrefix = game.NewIDPrefix()
+	gs.FinalDepth = 3
+
+	class, err := game.GetClass("warrior")
+	if err != nil {
+		t.Fatal(err)
+	}
+	char := game.NewCharacter("Hero")
+	char.ID = gs.NewID()
+	char.CreatedAt = time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	char.ApplyClass("warrior", class)
+	gs.Character = char
+	gs.GiveStartingItems(class)
+
+	gen := generator.NewLevelGenerator(seed, 1).WithIDPrefix(gs.IDPrefix).WithSize(5, 5)
+	dungeon, rooms, connections, err := gen.GenerateDungeon(1)
+	if err != nil {
+		t.Fatalf("generating dungeon: %v", err)
+	}
+	dungeon.CreatedAt = char.CreatedAt
+	if err := gs.EnterLevel(dungeon, rooms, connections); err != nil {
+		t.Fatalf("entering level: %v", err)
+	}
+	entrance := gs.GetEntrance()
+	char.CurrentRoomID = entrance.ID
+	gs.MarkRoomVisited(entrance.ID)
+	for _, room := range rooms {
+		monsters, items, traps := gen.PopulateRoom(room, generator.LevelDifficulty(room, entrance, 1))
+		for _, m := range monsters {
+			gs.AddMonster(m)
+		}
+		for _, item := range items {
+			gs.AddItem(item)
+		}
+		for _, trap := range traps {
+			gs.AddTrap(trap)
+		}
+	}
+	for _, key := range gen.PlaceLocks(rooms, connections) {
+		gs.AddItem(key)
+	}
+	if merchant := gen.PlaceMerchant(rooms, 1); merchant != nil {
+		gs.AddMerchant(merchant)
+	}
+	gs.RecordAction("new_game", map[string]interface{}{"seed": float64(seed), "class": "warrior"})
+
+	// Play on: a kill, some wounds, a poisoning, gold found and spent
+	monsterIDs := make([]string, 0, len(gs.Monsters))
+	for id := range gs.Monsters {
+		monsterIDs = append(monsterIDs, id)
+	}
+	if len(monsterIDs) == 0 {
+		t.Fatal("the generated level has no monsters")
+	}
+	sort.Strings(monsterIDs)
+	gs.KillMonster(monsterIDs[0])
+	char.TakeDamage(5)
+	char.ApplyEffect(game.StatusEffect{Type: game.EffectPoison, Potency: 1, Duration: 3})
+	char.Mana = 1
+	char.Gold = 12
+	gs.GoldCollected = 30
+	for _, room := range rooms {
+		if !room.IsEntrance {
+			gs.MarkRoomVisited(room.ID)
+			gs.RevealedRooms[room.ID] = true
+			gs.PreviousRoomID = room.ID
+			break
+		}
+	}
+	gs.Dice.Roll(20)
+	gs.TurnNumber = 7
+	gs.TurnContext.TurnsInRoom = 2
+	gs.RecordAction("look", map[string]interface{}{})
+	return gs
+}
+
+// savedSnapshot is everything SaveGame promises to keep, encoded so two
+// states can be compared in one go
+func savedSnapshot(t *testing.T, gs *game.GameState) string {
+	t.Helper()
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"character":         gs.Character,
+		"dungeon":           gs.Dungeon,
+		"rooms":             gs.Rooms,
+		"connections":       gs.Connections,
+		"monsters":          gs.Monsters,
+		"items":             gs.Items,
+		"traps":             gs.Traps,
+		"merchants":         gs.Merchants,
+		"visitedRooms":      gs.VisitedRooms,
+		"revealedRooms":     gs.RevealedRooms,
+		"gameOver":          gs.GameOver,
+		"victory":           gs.Victory,
+		"turnNumber":        gs.TurnNumber,
+		"previousRoomId":    gs.PreviousRoomID,
+		"finalDepth":        gs.FinalDepth,
+		"kills":             gs.Kills,
+		"goldCollected":     gs.GoldCollected,
+		"roomsVisited":      gs.RoomsVisited,
+		"roomsGenerated":    gs.RoomsGenerated,
+		"score":             gs.Score,
+		"turnsInRoom":       gs.TurnContext.TurnsInRoom,
+		"consecutiveCombat": gs.TurnContext.ConsecutiveCombat,
+		"seed":              gs.Dice.Seed(),
+		"draws":             gs.Dice.Draws(),
+		"idPrefix":          gs.IDPrefix,
+		"actionLog":         gs.ActionLog,
+	}, "", "  ")
+	if err != nil {
+		t.Fatalf("encoding game state: %v", err)
+	}
+	return string(data)
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	store := newTestDB(t)
+	gs := newGameInProgress(t)
+
+	if err := store.SaveGame("test", gs); err != nil {
+		t.Fatalf("saving game: %v", err)
+	}
+	loaded, err := store.LoadGame("test")
+	if err != nil {
+		t.Fatalf("loading game: %v", err)
+	}
+	if loaded == nil {
+		t.Fatal("the saved game was not found")
+	}
+
+	if want, got := savedSnapshot(t, gs), savedSnapshot(t, loaded); got != want {
+		t.Errorf("loaded game differs from the saved one\nsaved:\n%s\nloaded:\n%s", want, got)
+	}
+
+	// The resumed dice carry on where the saved ones stopped
+	if want, got := gs.Dice.Roll(1000), loaded.Dice.Roll(1000); got != want {
+		t.Errorf("resumed dice rolled %d, want %d", got, want)
+	}
+}
+
+func TestLoadGameMissing(t *testing.T) {
+	store := newTestDB(t)
+	gs, err := store.LoadGame("missing")
+	if err != nil {
+		t.Fatalf("loading a missing game: %v", err)
+	}
+	if gs != nil {
+		t.Error("loaded a game that was never saved")
+	}
+}
+
+// TestLoadLegacyGame loads a save from before the newer columns existed,
+// when they are NULL, and checks each falls back as documented in LoadGame
+func TestLoadLegacyGame(t *testing.T) {
+	store := newTestDB(t)
+	gs := newGameInProgress(t)
+	if err := store.SaveGame("legacy", gs); err != nil {
+		t.Fatalf("saving game: %v", err)
+	}
+
+	if _, err := store.Conn().Exec(`UPDATE game_sessions SET seed = NULL, dice_draws = NULL, id_prefix = NULL,
+		action_log = NULL, kills = NULL, gold_collected = NULL, final_depth = NULL, revealed_rooms = NULL,
+		rooms_visited = NULL, rooms_generated = NULL WHERE id = ?`, "legacy"); err != nil {
+		t.Fatalf("clearing session columns: %v", err)
+	}
+	if _, err := store.Conn().Exec(`UPDATE characters SET mana = NULL, max_mana = NULL, effects = NULL
+		WHERE id = ?`, gs.Character.ID); err != nil {
+		t.Fatalf("clearing character columns: %v", err)
+	}
+
+	loaded, err := store.LoadGame("legacy")
+	if err != nil {
+		t.Fatalf("loading legacy game: %v", err)
+	}
+	if loaded == nil {
+		t.Fatal("the legacy game was not found")
+	}
+
+	// Seedless saves still get dice to keep playing with
+	if loaded.Dice == nil {
+		t.Fatal("legacy game has no dice")
+	}
+	loaded.Dice.Roll(20)
+	if len(loaded.ActionLog) != 0 {
+		t.Errorf("legacy game has %d logged actions, want none", len(loaded.ActionLog))
+	}
+
+	// Kills fall back to this level's dead monsters, gold collected to gold on hand
+	if loaded.Kills != 1 {
+		t.Errorf("legacy kills = %d, want the 1 dead monster", loaded.Kills)
+	}
+	if loaded.GoldCollected != gs.Character.Gold {
+		t.Errorf("legacy gold collected = %d, want the %d on hand", loaded.GoldCollected, gs.Character.Gold)
+	}
+
+	// Exploration falls back to this level's rooms
+	if loaded.RoomsVisited != len(gs.VisitedRooms) || loaded.RoomsGenerated != len(gs.Rooms) {
+		t.Errorf("legacy rooms visited %d of %d, want %d of %d",
+			loaded.RoomsVisited, loaded.RoomsGenerated, len(gs.VisitedRooms), len(gs.Rooms))
+	}
+
+	// The run ends at the level the save was on
+	if loaded.FinalDepth != gs.Dungeon.Depth {
+		t.Errorf("legacy final depth = %d, want the saved level %d", loaded.FinalDepth, gs.Dungeon.Depth)
+	}
+
+	// Characters from before mana start with their class's full pool
+	char := loaded.Character
+	want := game.Classes[char.Class].MaxMana
+	if char.MaxMana != want || char.Mana != want {
+		t.Errorf("legacy mana = %d/%d, want a full %d", char.Mana, char.MaxMana, want)
+	}
+	if len(char.Effects) != 0 {
+		t.Errorf("legacy character has effects %v", char.Effects)
+	}
+
+	// Equipment is restored from the items' is_equipped flags
+	for _, slot := range []struct {
+		name      string
+		want, got *string
+	}{
+		{"weapon", gs.Character.EquippedWeaponID, char.EquippedWeaponID},
+		{"armor", gs.Character.EquippedArmorID, char.EquippedArmorID},
+	} {
+		if slot.want == nil {
+			t.Fatalf("the warrior started without a %s equipped", slot.name)
+		}
+		if slot.got == nil || *slot.got != *slot.want {
+			t.Errorf("legacy %s not re-equipped: got %v, want %s", slot.name, slot.got, *slot.want)
+		}
+	}
+}
@@ -11,7 +11,16 @@ CREATE TABLE IF NOT EXISTS characters (
     current_room_id TEXT,
     is_alive BOOLEAN DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    died_at TIMESTAMP
+    died_at TIMESTAMP,
+    gold INTEGER DEFAULT 0,
+    level INTEGER DEFAULT 1,
+    xp INTEGER DEFAULT 0,
+    stat_points INTEGER DEFAULT 0, -- level-ups not yet spent on a stat
+    class TEXT DEFAULT 'adventurer',
+    effects TEXT, -- JSON array of active status effects
+    mana INTEGER DEFAULT 0,
+    max_mana INTEGER,
+    shield INTEGER DEFAULT 0 -- damage a ward will absorb
 );
 
 -- Rooms in the dungeon
@@ -33,6 +42,8 @@ CREATE TABLE IF NOT EXISTS room_connections (
     room_id TEXT NOT NULL,
     direction TEXT NOT NULL, -- north, south, east, west
     connected_room_id TEXT NOT NULL,
+    is_locked BOOLEAN DEFAULT 0,
+    key_id TEXT, -- key item that opens the door
     FOREIGN KEY (room_id) REFERENCES rooms(id),
     FOREIGN KEY (connected_room_id) REFERENCES rooms(id)
 );
@@ -48,6 +59,8 @@ CREATE TABLE IF NOT EXISTS monsters (
     room_id TEXT NOT NULL,
     is_alive BOOLEAN DEFAULT 1,
     loot_table TEXT, -- JSON array of possible item drops
+    on_hit TEXT, -- JSON status effect its hits may inflict
+    effects TEXT, -- JSON array of active status effects
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (room_id) REFERENCES rooms(id)
 );
@@ -61,9 +74,14 @@ CREATE TABLE IF NOT EXISTS items (
     damage INTEGER DEFAULT 0,
     armor INTEGER DEFAULT 0,
     healing INTEGER DEFAULT 0,
+    rarity TEXT DEFAULT 'common', -- common, uncommon, rare, legendary
+    value INTEGER DEFAULT 0, -- worth in gold
     room_id TEXT,
     character_id TEXT,
     is_equipped BOOLEAN DEFAULT 0,
+    effect TEXT, -- JSON status effect a consumable applies
+    cures TEXT, -- status effect a consumable removes
+    on_hit TEXT, -- JSON status effect a weapon's hits may inflict
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (room_id) REFERENCES rooms(id),
     FOREIGN KEY (character_id) REFERENCES characters(id)
@@ -73,6 +91,7 @@ CREATE TABLE IF NOT EXISTS items (
 CREATE TABLE IF NOT EXISTS traps (
     id TEXT PRIMARY KEY,
     room_id TEXT NOT NULL,
+    name TEXT,
     description TEXT,
     damage INTEGER NOT NULL,
     is_triggered BOOLEAN DEFAULT 0,
@@ -82,6 +101,17 @@ CREATE TABLE IF NOT EXISTS traps (
     FOREIGN KEY (room_id) REFERENCES rooms(id)
 );
 
+-- Merchants in rooms
+CREATE TABLE IF NOT EXISTS merchants (
+    id TEXT PRIMARY KEY,
+    room_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    stock TEXT, -- JSON array of items for sale
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (room_id) REFERENCES rooms(id)
+);
+
 -- Game events log (for UI generation and history)
 CREATE TABLE IF NOT EXISTS game_events (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -98,6 +128,51 @@ CREATE TABLE IF NOT EXISTS dungeons (
     id TEXT PRIMARY KEY,
     seed INTEGER,
     depth INTEGER DEFAULT 1,
+    width INTEGER DEFAULT 5,
+    height INTEGER DEFAULT 5,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Saved games, one per session (ties a character to the dungeon they are in)
+CREATE TABLE IF NOT EXISTS game_sessions (
+    id TEXT PRIMARY KEY,
+    character_id TEXT NOT NULL,
+    dungeon_id TEXT NOT NULL,
+    game_over BOOLEAN DEFAULT 0,
+    victory BOOLEAN DEFAULT 0,
+    visited_rooms TEXT, -- JSON array of room IDs
+    revealed_rooms TEXT, -- JSON array of room IDs shown by the reveal ability
+    turns_in_room INTEGER DEFAULT 0,
+    consecutive_combat INTEGER DEFAULT 0,
+    seed INTEGER, -- dice seed, for replays
+    id_prefix TEXT,
+    dice_draws INTEGER DEFAULT 0,
+    action_log TEXT, -- JSON array of recorded tool calls
+    turn_number INTEGER DEFAULT 0,
+    previous_room_id TEXT, -- room to flee back to
+    final_depth INTEGER, -- depth whose exit escapes the dungeon
+    kills INTEGER, -- monsters defeated across all levels
+    gold_collected INTEGER, -- treasure picked up across all levels
+    rooms_visited INTEGER, -- rooms visited across all levels
+    rooms_generated INTEGER, -- rooms generated across all levels
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (character_id) REFERENCES characters(id),
+    FOREIGN KEY (dungeon_id) REFERENCES dungeons(id)
+);
+
+-- Final scores of finished runs, kept after their session is replaced
+CREATE TABLE IF NOT EXISTS scores (
+    character_id TEXT PRIMARY KEY,
+    session_id TEXT,
+    character_name TEXT NOT NULL,
+    seed INTEGER,
+    depth INTEGER DEFAULT 0,
+    kills INTEGER DEFAULT 0,
+    gold INTEGER DEFAULT 0,
+    turns INTEGER DEFAULT 0,
+    exploration REAL DEFAULT 0,
+    victory BOOLEAN DEFAULT 0,
+    total INTEGER NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -119,3 +194,5 @@ CREATE INDEX IF NOT EXISTS idx_items_room ON items(room_id);
 CREATE INDEX IF NOT EXISTS idx_items_character ON items(character_id);
 CREATE INDEX IF NOT EXISTS idx_room_connections ON room_connections(room_id);
 CREATE INDEX IF NOT EXISTS idx_events_character ON game_events(character_id);
+CREATE INDEX IF NOT EXISTS idx_rooms_dungeon ON rooms(dungeon_id);
+CREATE INDEX IF NOT EXISTS idx_scores_total ON scores(total DESC);
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// ScoreEntry is a finished run in the score history
+type ScoreEntry struct {
+	CharacterName string      `json:"characterName"`
+	Seed          int64       `json:"seed"`
+	Score         *game.Score `json:"score"`
+	CreatedAt     time.Time   `json:"createdAt"`
+}
+
+// insertScore records a finished run's score. A run is only scored once, so
+// saves after the game ends leave the first record alone.
+func insertScore(ex execer, sessionID string, gs *game.GameState) error {
+	s := gs.Score
+	_, err := ex.Exec(`INSERT OR IGNORE INTO scores
+		(character_id, session_id, character_name, seed, depth, kills, gold, turns, exploration, victory, total, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		gs.Character.ID, sessionID, gs.Character.Name, gs.Dungeon.Seed, s.Depth, s.Kills, s.Gold, s.Turns,
+		s.Exploration, s.Victory, s.Total, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save score: %w", err)
+	}
+	return nil
+}
+
+// getScore loads the recorded score for a character's run. It returns nil if
+// the run has not been scored.
+func (db *DB) getScore(characterID string) (*game.Score, error) {
+	s := &game.Score{}
+	err := db.conn.QueryRow(`SELECT depth, kills, gold, turns, exploration, victory, total
+		FROM scores WHERE character_id = ?`, characterID).
+		Scan(&s.Depth, &s.Kills, &s.Gold, &s.Turns, &s.Exploration, &s.Victory, &s.Total)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load score: %w", err)
+	}
+	return s, nil
+}
+
+// GetHighScores returns the best finished runs, highest total first
+func (db *DB) GetHighScores(limit int) ([]*ScoreEntry, error) {
+	rows, err := db.conn.Query(`SELECT character_name, seed, depth, kills, gold, turns, exploration,
+		victory, total, created_at FROM scores ORDER BY total DESC, created_at ASC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scores: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*ScoreEntry, 0)
+	for rows.Next() {
+		e := &ScoreEntry{Score: &game.Score{}}
+		var seed sql.NullInt64
+		if err := rows.Scan(&e.CharacterName, &seed, &e.Score.Depth, &e.Score.Kills, &e.Score.Gold,
+			&e.Score.Turns, &e.Score.Exploration, &e.Score.Victory, &e.Score.Total, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to load score: %w", err)
+		}
+		e.Seed = seed.Int64
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,287 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ability kinds
+const (
+	AbilityDamage = "damage" // Hurts a monster
+	AbilityHeal   = "heal"   // Restores the caster's HP
+	AbilityShield = "shield" // Absorbs damage from monster attacks
+	AbilityReveal = "reveal" // Shows every room of the level on the map
+)
+
+// Mana constants
+const (
+	ManaRegenPerTurn = 1  // Mana recovered at the end of every turn
+	ManaPerLevel     = 2  // MaxMana gained per level by characters with mana
+	MaxShield        = 10 // Most damage a shield can absorb at once
+)
+
+// Ability is a spell or trained technique a character can cast for mana
+type Ability struct {
+	Name        string
+	Description string
+	Kind        string // damage, heal, shield, reveal
+	ManaCost    int
+	Power       int  // Base damage, healing or shield; damage adds a d6
+	Area        bool // Damage hits every monster in the room
+	Peaceful    bool // Can only be cast with no monsters in the room
+}
+
+// Abilities are every ability a class can know, keyed by the name cast accepts
+var Abilities = map[string]*Ability{
+	"firebolt": {
+		Name:        "Firebolt",
+		Description: "Hurl a bolt of fire that never misses",
+		Kind:        AbilityDamage,
+		ManaCost:    5,
+		Power:       6,
+	},
+	"flamewave": {
+		Name:        "Flame Wave",
+		Description: "Sweep a wave of fire across every monster in the room",
+		Kind:        AbilityDamage,
+		ManaCost:    8,
+		Power:       2,
+		Area:        true,
+	},
+	"mend": {
+		Name:        "Mend",
+		Description: "Close your wounds with a murmured charm",
+		Kind:        AbilityHeal,
+		ManaCost:    4,
+		Power:       8,
+	},
+	"ward": {
+		Name:        "Ward",
+		Description: "Raise a shield that absorbs damage from monster attacks",
+		Kind:        AbilityShield,
+		ManaCost:    4,
+		Power:       5,
+	},
+	"reveal": {
+		Name:        "Reveal",
+		Description: "Sense the layout of the whole level, marking every room on the map",
+		Kind:        AbilityReveal,
+		ManaCost:    6,
+		Peaceful:    true,
+	},
+}
+
+// AbilityNames returns every ability name in alphabetical order
+func AbilityNames() []string {
+	names := make([]string, 0, len(Abilities))
+	for name := range Abilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NeedsTarget returns true if the ability is aimed at a monster
+func (a *Ability) NeedsTarget() bool {
+	return a.Kind == AbilityDamage
+}
+
+// KnownAbilities returns the names of the abilities the character's class knows
+func (c *Character) KnownAbilities() []string {
+	if class, ok := Classes[c.Class]; ok {
+		return class.Abilities
+	}
+	return nil
+}
+
+// KnowsAbility returns true if the character can cast an ability
+func (c *Character) KnowsAbility(name string) bool {
+	for _, known := range c.KnownAbilities() {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreMana recovers mana, up to MaxMana
+func (c *Character) RestoreMana(amount int) {
+	c.Mana = min(c.Mana+amount, c.MaxMana)
+}
+
+// AbsorbDamage lets the character's shield soak up incoming damage, returning
+// the damage left over
+func (c *Character) AbsorbDamage(damage int) int {
+	absorbed := min(c.Shield, damage)
+	c.Shield -= absorbed
+	return damage - absorbed
+}
+
+// PrepareCast checks that the character can cast an ability right now and
+// finds its targets: every monster in the room for area abilities, the chosen
+// monster for other damage abilities (or the only one present), nothing
+// otherwise. Nothing is spent.
+func (gs *GameState) PrepareCast(name, targetID string) (*Ability, []*Monster, error) {
+	if gs.Character == nil {
+		return nil, nil, fmt.Errorf("no character")
+	}
+	ability, ok := Abilities[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown ability: %s (choose one of: %s)", name, strings.Join(AbilityNames(), ", "))
+	}
+	char := gs.Character
+	if !char.KnowsAbility(name) {
+		known := char.KnownAbilities()
+		if len(known) == 0 {
+			return nil, nil, fmt.Errorf("you don't know any abilities")
+		}
+		return nil, nil, fmt.Errorf("you don't know %s (you know: %s)", ability.Name, strings.Join(known, ", "))
+	}
+	if err := gs.checkCast(ability); err != nil {
+		return nil, nil, err
+	}
+	if !ability.NeedsTarget() {
+		return ability, nil, nil
+	}
+	if ability.Area {
+		return ability, gs.GetRoomMonsters(char.CurrentRoomID), nil
+	}
+	target, err := gs.targetMonster(targetID, "cast it at")
+	if err != nil {
+		return nil, nil, err
+	}
+	return ability, []*Monster{target}, nil
+}
+
+// CanCast returns true if the character knows an ability and could cast it
+// right now
+func (gs *GameState) CanCast(name string) bool {
+	ability, ok := Abilities[name]
+	if !ok || gs.Character == nil || !gs.Character.KnowsAbility(name) {
+		return false
+	}
+	return gs.checkCast(ability) == nil
+}
+
+// checkCast checks the character has the mana for an ability and that the
+// situation allows it: peaceful abilities need an empty room, and damage
+// abilities need something to hit
+func (gs *GameState) checkCast(ability *Ability) error {
+	char := gs.Character
+	if char.Mana < ability.ManaCost {
+		return fmt.Errorf("%s needs %d mana - you have %d", ability.Name, ability.ManaCost, char.Mana)
+	}
+	inCombat := gs.HasMonstersInRoom(char.CurrentRoomID)
+	if ability.Peaceful && inCombat {
+		return fmt.Errorf("you can't concentrate on %s with monsters about", ability.Name)
+	}
+	if ability.NeedsTarget() && !inCombat {
+		return fmt.Errorf("there is nothing here to cast %s at", ability.Name)
+	}
+	return nil
+}
+
+// applyAbility spends an ability's mana and applies it: damage to each
+// target monster, healing or a shield to the caster. Revealing the map needs
+// the game state, so it is left to the caller. Returns the cast and, for
+// damage abilities, one attack per target.
+func applyAbility(dice *Dice, caster *Character, ability *Ability, targets []*Monster) (*CastResult, []*AttackResult) {
+	caster.Mana -= ability.ManaCost
+	cast := &CastResult{
+		Ability:  ability.Name,
+		Kind:     ability.Kind,
+		ManaCost: ability.ManaCost,
+	}
+
+	var attacks []*AttackResult
+	switch ability.Kind {
+	case AbilityDamage:
+		for _, target := range targets {
+			damage := ability.Power + dice.Roll(D6)
+			target.HP -= damage
+			if target.HP <= 0 {
+				target.HP = 0
+				target.IsAlive = false
+			}
+			cast.TargetIDs = append(cast.TargetIDs, target.ID)
+			cast.Amount += damage
+			attacks = append(attacks, &AttackResult{
+				AttackerName: caster.Name,
+				TargetID:     target.ID,
+				TargetName:   target.Name,
+				Damage:       damage,
+				WasHit:       true,
+				RemainingHP:  target.HP,
+			})
+		}
+	case AbilityHeal:
+		oldHP := caster.HP
+		caster.Heal(ability.Power)
+		cast.Amount = caster.HP - oldHP
+	case AbilityShield:
+		oldShield := caster.Shield
+		caster.Shield = min(caster.Shield+ability.Power, MaxShield)
+		cast.Amount = caster.Shield - oldShield
+	}
+
+	cast.ManaLeft = caster.Mana
+	return cast, attacks
+}
+
+// describeCast describes what a cast did, e.g. "You cast Mend and recover 8 HP."
+// targets and attacks are the monsters a damage ability hit and how hard.
+func describeCast(cast *CastResult, caster *Character, targets []*Monster, attacks []*AttackResult) string {
+	switch cast.Kind {
+	case AbilityDamage:
+		if len(targets) == 1 {
+			target := targets[0]
+			if !target.IsAlive {
+				return fmt.Sprintf("You cast %s at the %s for %d damage! The %s collapses!",
+					cast.Ability, target.Name, cast.Amount, target.Name)
+			}
+			return fmt.Sprintf("You cast %s at the %s for %d damage! (%d/%d HP)",
+				cast.Ability, target.Name, cast.Amount, target.HP, target.MaxHP)
+		}
+		msg := fmt.Sprintf("You cast %s!", cast.Ability)
+		for i, target := range targets {
+			if !target.IsAlive {
+				msg += fmt.Sprintf(" The %s takes %d damage and collapses!", target.Name, attacks[i].Damage)
+			} else {
+				msg += fmt.Sprintf(" The %s takes %d damage. (%d/%d HP)", target.Name, attacks[i].Damage, target.HP, target.MaxHP)
+			}
+		}
+		return msg
+	case AbilityHeal:
+		return fmt.Sprintf("You cast %s and recover %d HP. (HP: %d/%d)", cast.Ability, cast.Amount, caster.HP, caster.MaxHP)
+	case AbilityShield:
+		return fmt.Sprintf("You cast %s. Your shield will absorb %d damage.", cast.Ability, caster.Shield)
+	case AbilityReveal:
+		return fmt.Sprintf("You cast %s. The layout of the level unfolds in your mind: %d room(s) revealed.", cast.Ability, cast.Amount)
+	}
+	return fmt.Sprintf("You cast %s.", cast.Ability)
+}
+
+// CastAbility casts an ability outside combat. Damage abilities need a
+// monster, so they can only be cast in combat through ExecuteCombatTurn.
+// Returns the result and a message describing it.
+func (gs *GameState) CastAbility(ability *Ability) (*CastResult, string) {
+	cast, _ := applyAbility(gs.Dice, gs.Character, ability, nil)
+	if ability.Kind == AbilityReveal {
+		cast.Amount = gs.RevealMap()
+	}
+	return cast, describeCast(cast, gs.Character, nil, nil)
+}
+
+// RevealMap marks every room of the level as revealed, returning how many
+// were not already visited or revealed
+func (gs *GameState) RevealMap() int {
+	revealed := 0
+	for roomID := range gs.Rooms {
+		if !gs.VisitedRooms[roomID] && !gs.RevealedRooms[roomID] {
+			revealed++
+		}
+		gs.RevealedRooms[roomID] = true
+	}
+	return revealed
+}
@@ -6,6 +6,19 @@ import (
 	"time"
 )
 
+// Leveling constants
+const (
+	XPPerLevel   = 20 // Reaching level n takes XPPerLevel * (1 + 2 + ... + n-1) XP
+	HPPerLevel   = 5  // MaxHP gained per level
+	StatIncrease = 2  // Strength or Dexterity gained per stat point spent
+)
+
+// Carrying constants
+const (
+	BaseCarrySlots  = 6 // Inventory slots every character has
+	StrengthPerSlot = 2 // Strength needed for each extra slot
+)
+
 // CharacterService handles character operations
 type CharacterService struct {
 	// TODO: Add database reference
@@ -20,6 +33,7 @@ func NewCharacter(name string) *Character {
 		MaxHP:     20,
 		Strength:  10,
 		Dexterity: 10,
+		Level:     1,
 		IsAlive:   true,
 		CreatedAt: time.Now(),
 	}
@@ -51,6 +65,54 @@ func (c *Character) Heal(amount int) {
 	}
 }
 
+// XPForLevel returns the total XP needed to reach a level
+func XPForLevel(level int) int {
+	return XPPerLevel * level * (level - 1) / 2
+}
+
+// MonsterXP returns the XP awarded for defeating a monster, based on its
+// scaled HP and damage
+func MonsterXP(m *Monster) int {
+	return m.MaxHP/2 + m.Damage
+}
+
+// AwardXP adds XP and levels the character up for every threshold crossed.
+// Each level raises MaxHP (healing by the same amount) and grants a stat
+// point to spend with SpendStatPoint. Returns the number of levels gained.
+func (c *Character) AwardXP(xp int) int {
+	c.XP += xp
+	gained := 0
+	for c.XP >= XPForLevel(c.Level+1) {
+		c.Level++
+		c.MaxHP += HPPerLevel
+		c.HP += HPPerLevel
+		c.StatPoints++
+		if c.MaxMana > 0 {
+			c.MaxMana += ManaPerLevel
+			c.Mana += ManaPerLevel
+		}
+		gained++
+	}
+	return gained
+}
+
+// SpendStatPoint raises strength or dexterity using an unspent stat point
+func (c *Character) SpendStatPoint(stat string) error {
+	if c.StatPoints <= 0 {
+		return fmt.Errorf("no stat points to spend - defeat monsters to gain levels")
+	}
+	switch stat {
+	case "strength":
+		c.Strength += StatIncrease
+	case "dexterity":
+		c.Dexterity += StatIncrease
+	default:
+		return fmt.Errorf("unknown stat: %s (choose strength or dexterity)", stat)
+	}
+	c.StatPoints--
+	return nil
+}
+
 // CanMove checks if character can move in a direction
 func (c *Character) CanMove() error {
 	if !c.IsAlive {
@@ -68,6 +130,26 @@ func (c *Character) EquipItem(item *Item) error {
 	return nil
 }
 
+// UnequipItem takes off an equipped weapon or armor
+func (c *Character) UnequipItem(item *Item) error {
+	switch {
+	case c.EquippedWeaponID != nil && *c.EquippedWeaponID == item.ID:
+		c.EquippedWeaponID = nil
+	case c.EquippedArmorID != nil && *c.EquippedArmorID == item.ID:
+		c.EquippedArmorID = nil
+	default:
+		return fmt.Errorf("the %s is not equipped", item.Name)
+	}
+	item.IsEquipped = false
+	return nil
+}
+
+// CarryCapacity returns how many items the character can carry. Stronger
+// characters carry more.
+func (c *Character) CarryCapacity() int {
+	return BaseCarrySlots + c.Strength/StrengthPerSlot
+}
+
 // UseConsumable uses a consumable item
 func (c *Character) UseConsumable(item *Item) error {
 	if item.Type != "consumable" {
@@ -77,7 +159,11 @@ func (c *Character) UseConsumable(item *Item) error {
 	if item.Healing > 0 {
 		c.Heal(item.Healing)
 	}
-	
-	// TODO: Handle other consumable effects
+	if item.Cures != "" {
+		c.CureEffect(item.Cures)
+	}
+	if item.Effect != nil {
+		c.ApplyEffect(*item.Effect)
+	}
 	return nil
 }
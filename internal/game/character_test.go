@@ -0,0 +1,98 @@
+package game
+
+import "testing"
+
+func TestXPForLevel(t *testing.T) {
+	for level, want := range map[int]int{1: 0, 2: 20, 3: 60, 4: 120, 5: 200} {
+		if got := XPForLevel(level); got != want {
+			t.Errorf("XPForLevel(%d) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+func TestMonsterXP(t *testing.T) {
+	m := &Monster{MaxHP: 9, Damage: 3}
+	if got := MonsterXP(m); got != 7 {
+		t.Errorf("MonsterXP = %d, want half of 9 HP plus 3 damage = 7", got)
+	}
+}
+
+func TestAwardXP(t *testing.T) {
+	tests := []struct {
+		name   string
+		xp     int
+		levels int
+	}{
+		{"short of a level", XPForLevel(2) - 1, 0},
+		{"exactly a level", XPForLevel(2), 1},
+		{"several levels at once", XPForLevel(4) + 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			char := NewCharacter("Hero")
+			char.MaxMana, char.Mana = 6, 2
+			char.TakeDamage(4)
+
+			if got := char.AwardXP(tt.xp); got != tt.levels {
+				t.Fatalf("gained %d levels, want %d", got, tt.levels)
+			}
+			if char.Level != 1+tt.levels || char.XP != tt.xp {
+				t.Errorf("level %d with %d XP, want level %d with %d", char.Level, char.XP, 1+tt.levels, tt.xp)
+			}
+			if char.StatPoints != tt.levels {
+				t.Errorf("%d stat points, want one per level", char.StatPoints)
+			}
+
+			// Each level raises max HP and heals by as much; mana grows the same way
+			if want := 20 + tt.levels*HPPerLevel; char.MaxHP != want || char.HP != want-4 {
+				t.Errorf("HP %d/%d, want %d/%d", char.HP, char.MaxHP, want-4, want)
+			}
+			if want := 6 + tt.levels*ManaPerLevel; char.MaxMana != want || char.Mana != want-4 {
+				t.Errorf("mana %d/%d, want %d/%d", char.Mana, char.MaxMana, want-4, want)
+			}
+		})
+	}
+}
+
+func TestAwardXPAccumulates(t *testing.T) {
+	char := NewCharacter("Hero")
+	if char.AwardXP(15) != 0 {
+		t.Fatal("levelled up short of the threshold")
+	}
+	if levels := char.AwardXP(5); levels != 1 || char.Level != 2 {
+		t.Errorf("XP adding up to the threshold gained %d levels, now level %d; want 1, level 2", levels, char.Level)
+	}
+	if levels := char.AwardXP(XPForLevel(3) - char.XP - 1); levels != 0 || char.Level != 2 {
+		t.Errorf("XP short of level 3 gained %d levels, now level %d", levels, char.Level)
+	}
+}
+
+func TestSpendStatPoint(t *testing.T) {
+	char := NewCharacter("Hero")
+	if err := char.SpendStatPoint("strength"); err == nil {
+		t.Error("spent a stat point without having any")
+	}
+
+	char.AwardXP(XPForLevel(3))
+	if err := char.SpendStatPoint("strength"); err != nil {
+		t.Fatalf("spending on strength: %v", err)
+	}
+	if err := char.SpendStatPoint("dexterity"); err != nil {
+		t.Fatalf("spending on dexterity: %v", err)
+	}
+	if char.Strength != 10+StatIncrease || char.Dexterity != 10+StatIncrease {
+		t.Errorf("STR %d, DEX %d; want both raised to %d", char.Strength, char.Dexterity, 10+StatIncrease)
+	}
+	if char.StatPoints != 0 {
+		t.Errorf("%d stat points left, want 0", char.StatPoints)
+	}
+
+	// An unknown stat keeps the point
+	char.AwardXP(XPForLevel(4) - char.XP)
+	if err := char.SpendStatPoint("charisma"); err == nil {
+		t.Error("spent a stat point on an unknown stat")
+	}
+	if char.StatPoints != 1 {
+		t.Errorf("%d stat points after a rejected spend, want 1", char.StatPoints)
+	}
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultClass is the class of a character created without one: the plain
+// adventurer every character used to be
+const DefaultClass = "adventurer"
+
+// Class defines a character class: starting stats, starting equipment, a
+// passive bonus applied in combat and the abilities it can cast
+type Class struct {
+	Name          string
+	Description   string
+	MaxHP         int
+	Strength      int
+	Dexterity     int
+	MaxMana       int
+	StartingItems []Item // Copied into the inventory; weapons and armor start equipped
+	Passive       Passive
+	Abilities     []string // Names of the abilities the class can cast
+}
+
+// Passive is a class's always-on combat bonus
+type Passive struct {
+	Name         string
+	Description  string
+	AttackBonus  int // Added to the player's attack rolls
+	DamageBonus  int // Added to the player's damage
+	DefenseBonus int // Added to the player's defense against monster attacks
+	FleeBonus    int // Added to the player's flee rolls
+}
+
+// Classes are the playable classes, keyed by the name new_game accepts
+var Classes = map[string]*Class{
+	"adventurer": {
+		Name:        "Adventurer",
+		Description: "A jack of all trades who enters the dungeon with nothing but their wits.",
+		MaxHP:       20,
+		Strength:    10,
+		Dexterity:   10,
+	},
+	"warrior": {
+		Name:        "Warrior",
+		Description: "A hardened fighter who wades into melee behind a shield.",
+		MaxHP:       26,
+		Strength:    12,
+		Dexterity:   8,
+		MaxMana:     4,
+		StartingItems: []Item{
+			{Name: "Rusty Sword", Description: "An old sword, still sharp enough to cut.", Type: "weapon", Damage: 3, Rarity: "common"},
+			{Name: "Wooden Shield", Description: "A simple wooden shield that provides basic protection.", Type: "armor", Armor: 2, Rarity: "common"},
+		},
+		Passive: Passive{
+			Name:         "Stalwart",
+			Description:  "+2 defense against monster attacks",
+			DefenseBonus: 2,
+		},
+		Abilities: []string{"ward"},
+	},
+	"rogue": {
+		Name:        "Rogue",
+		Description: "A nimble scout who strikes first and slips away when things go badly.",
+		MaxHP:       18,
+		Strength:    9,
+		Dexterity:   14,
+		MaxMana:     6,
+		StartingItems: []Item{
+			{Name: "Dagger", Description: "A slim blade, easy to hide and quick to draw.", Type: "weapon", Damage: 2, Rarity: "common"},
+			{Name: "Health Potion", Description: "A red vial that restores health.", Type: "consumable", Healing: 10, Rarity: "common"},
+		},
+		Passive: Passive{
+			Name:        "Evasive",
+			Description: "+2 to attack rolls and +4 to flee rolls",
+			AttackBonus: 2,
+			FleeBonus:   4,
+		},
+		Abilities: []string{"reveal"},
+	},
+	"mage": {
+		Name:        "Mage",
+		Description: "A scholar of the arcane whose strikes crackle with stored power.",
+		MaxHP:       16,
+		Strength:    8,
+		Dexterity:   10,
+		MaxMana:     20,
+		StartingItems: []Item{
+			{Name: "Oak Staff", Description: "A knotted staff humming with faint energy.", Type: "weapon", Damage: 1, Rarity: "common"},
+			{Name: "Health Potion", Description: "A red vial that restores health.", Type: "consumable", Healing: 10, Rarity: "common"},
+			{Name: "Health Potion", Description: "A red vial that restores health.", Type: "consumable", Healing: 10, Rarity: "common"},
+		},
+		Passive: Passive{
+			Name:        "Arcane Strikes",
+			Description: "+3 damage on every hit",
+			DamageBonus: 3,
+		},
+		Abilities: []string{"firebolt", "flamewave", "mend", "ward", "reveal"},
+	},
+}
+
+// GetClass looks up a class by name
+func GetClass(name string) (*Class, error) {
+	class, ok := Classes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown class: %s (choose one of: %s)", name, strings.Join(ClassNames(), ", "))
+	}
+	return class, nil
+}
+
+// ClassNames returns the playable class names in alphabetical order
+func ClassNames() []string {
+	names := make([]string, 0, len(Classes))
+	for name := range Classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Passive returns the combat passive of the character's class, or no bonus
+// if the class is unknown
+func (c *Character) Passive() Passive {
+	if class, ok := Classes[c.Class]; ok {
+		return class.Passive
+	}
+	return Passive{}
+}
+
+// ApplyClass sets a new character's class and starting stats
+func (c *Character) ApplyClass(className string, class *Class) {
+	c.Class = className
+	c.MaxHP = class.MaxHP
+	c.HP = class.MaxHP
+	c.Strength = class.Strength
+	c.Dexterity = class.Dexterity
+	c.MaxMana = class.MaxMana
+	c.Mana = class.MaxMana
+}
+
+// GiveStartingItems puts the class's starting items in the character's
+// inventory with fresh IDs, equipping the first weapon and armor
+func (gs *GameState) GiveStartingItems(class *Class) []*Item {
+	char := gs.Character
+	items := make([]*Item, 0, len(class.StartingItems))
+	for _, template := range class.StartingItems {
+		item := template
+		item.ID = gs.NewID()
+		item.CharacterID = &char.ID
+		switch {
+		case item.Type == "weapon" && char.EquippedWeaponID == nil:
+			item.IsEquipped = true
+			char.EquippedWeaponID = &item.ID
+		case item.Type == "armor" && char.EquippedArmorID == nil:
+			item.IsEquipped = true
+			char.EquippedArmorID = &item.ID
+		}
+		gs.AddItem(&item)
+		items = append(items, &item)
+	}
+	return items
+}
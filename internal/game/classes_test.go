@@ -0,0 +1,169 @@
+package game
+
+import "testing"
+
+func TestDefaultClassIsPlainAdventurer(t *testing.T) {
+	class, err := GetClass(DefaultClass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	char := NewCharacter("Hero")
+	char.ApplyClass(DefaultClass, class)
+
+	if char.MaxHP != 20 || char.Strength != 10 || char.Dexterity != 10 {
+		t.Errorf("adventurer stats HP %d, STR %d, DEX %d; want 20, 10, 10", char.MaxHP, char.Strength, char.Dexterity)
+	}
+	if char.MaxMana != 0 || len(char.KnownAbilities()) != 0 {
+		t.Errorf("adventurer has %d mana and abilities %v, want none", char.MaxMana, char.KnownAbilities())
+	}
+	if char.Passive() != (Passive{}) {
+		t.Errorf("adventurer has passive %+v, want none", char.Passive())
+	}
+
+	// Without mana, levelling up doesn't grant any
+	char.AwardXP(XPForLevel(2))
+	if char.Level != 2 || char.MaxMana != 0 {
+		t.Errorf("after levelling to %d the adventurer has %d max mana, want none", char.Level, char.MaxMana)
+	}
+}
+
+func TestApplyClass(t *testing.T) {
+	for _, name := range ClassNames() {
+		t.Run(name, func(t *testing.T) {
+			class, err := GetClass(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			char := NewCharacter("Hero")
+			char.ApplyClass(name, class)
+
+			if char.Class != name || char.HP != class.MaxHP || char.MaxHP != class.MaxHP ||
+				char.Strength != class.Strength || char.Dexterity != class.Dexterity ||
+				char.Mana != class.MaxMana || char.MaxMana != class.MaxMana {
+				t.Errorf("character %+v does not start with the class's stats %+v", char, class)
+			}
+			if char.Passive() != class.Passive {
+				t.Errorf("passive %+v, want the class's %+v", char.Passive(), class.Passive)
+			}
+		})
+	}
+
+	if _, err := GetClass("bard"); err == nil {
+		t.Error("GetClass accepted an unknown class")
+	}
+	char := NewCharacter("Hero")
+	char.Class = "bard"
+	if char.Passive() != (Passive{}) {
+		t.Errorf("unknown class has passive %+v, want none", char.Passive())
+	}
+}
+
+func TestGiveStartingItems(t *testing.T) {
+	gs, _ := newTestState(t, 1)
+	class, err := GetClass("mage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.Character.ApplyClass("mage", class)
+
+	items := gs.GiveStartingItems(class)
+	if len(items) != len(class.StartingItems) {
+		t.Fatalf("got %d items, want the class's %d", len(items), len(class.StartingItems))
+	}
+	char := gs.Character
+	for i, item := range items {
+		if gs.Items[item.ID] != item || item.CharacterID == nil || *item.CharacterID != char.ID {
+			t.Errorf("%s is not in the character's inventory", item.Name)
+		}
+		if item.Name != class.StartingItems[i].Name || class.StartingItems[i].ID != "" {
+			t.Errorf("item %d is %s, want a fresh copy of %s", i, item.Name, class.StartingItems[i].Name)
+		}
+	}
+	if items[0].ID == items[1].ID || items[1].ID == items[2].ID {
+		t.Error("starting items share an ID")
+	}
+
+	// The staff is equipped; the potions are not, and there is no armor
+	if char.EquippedWeaponID == nil || *char.EquippedWeaponID != items[0].ID || !items[0].IsEquipped {
+		t.Errorf("the %s was not equipped", items[0].Name)
+	}
+	if items[1].IsEquipped || items[2].IsEquipped || char.EquippedArmorID != nil {
+		t.Error("equipped something other than the weapon")
+	}
+}
+
+// TestClassPassivesInCombat checks each passive moves the roll it promises
+// to: the same dice decide the round with and without the bonus, and over
+// enough seeds the bonus must change an outcome at least once
+func TestClassPassivesInCombat(t *testing.T) {
+	tests := []struct {
+		name   string
+		class  string
+		action string
+		// outcome is what the round decided, and want what it should have
+		// decided given its first d20 and d6 and the passive
+		outcome func(enhanced *EnhancedCombatResult) int
+		want    func(d20, d6 int, p Passive) int
+	}{
+		{"rogue attack bonus", "rogue", "attack",
+			func(e *EnhancedCombatResult) int { return btoi(e.PlayerAttacks[0].WasHit) },
+			func(d20, d6 int, p Passive) int { return btoi(d20+5+p.AttackBonus >= BaseDefense) }},
+		{"mage damage bonus", "mage", "attack",
+			func(e *EnhancedCombatResult) int { return e.PlayerAttacks[0].Damage },
+			func(d20, d6 int, p Passive) int {
+				if d20+5 < BaseDefense {
+					return 0
+				}
+				return d6 + 5 + p.DamageBonus
+			}},
+		{"warrior defense bonus", "warrior", "defend",
+			func(e *EnhancedCombatResult) int { return btoi(e.EnemyAttacks[0].WasHit) },
+			func(d20, d6 int, p Passive) int { return btoi(d20 >= BaseDefense+5+p.DefenseBonus) }},
+		{"rogue flee bonus", "rogue", "flee",
+			func(e *EnhancedCombatResult) int { return btoi(e.Fled) },
+			func(d20, d6 int, p Passive) int { return btoi(d20+5+p.FleeBonus >= BaseDefense+2) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passive := Classes[tt.class].Passive
+			mattered := false
+			for seed := int64(1); seed <= 100; seed++ {
+				// Default STR and DEX of 10 give +5 to hit, to damage and to defense
+				char := NewCharacter("Hero")
+				char.Class = tt.class
+				monster := &Monster{ID: "m", Name: "Ogre", HP: 50, MaxHP: 50, Damage: 4, IsAlive: true}
+
+				var enhanced *EnhancedCombatResult
+				switch tt.action {
+				case "attack":
+					_, enhanced, _ = ExecuteCombatTurn(NewDice(seed), char, nil, []*Monster{monster}, "attack", nil, 0, nil, 0)
+				case "defend":
+					// No targets: the monster's attack takes the first roll
+					_, enhanced, _ = ExecuteCombatTurn(NewDice(seed), char, []*Monster{monster}, nil, "attack", nil, 0, nil, 0)
+				case "flee":
+					_, enhanced, _ = ExecuteCombatTurn(NewDice(seed), char, []*Monster{monster}, nil, "flee", nil, 0, nil, 0)
+				}
+
+				dice := NewDice(seed)
+				d20, d6 := dice.Roll(D20), dice.Roll(D6)
+				if got, want := tt.outcome(enhanced), tt.want(d20, d6, passive); got != want {
+					t.Errorf("seed %d (d20 %d, d6 %d): got %d, want %d", seed, d20, d6, got, want)
+				}
+				if tt.want(d20, d6, passive) != tt.want(d20, d6, Passive{}) {
+					mattered = true
+				}
+			}
+			if !mattered {
+				t.Error("the passive never changed the outcome")
+			}
+		})
+	}
+}
+
+// btoi turns an outcome into a number for comparison
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
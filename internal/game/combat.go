@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
-	"time"
 )
 
 // Combat constants
@@ -16,200 +15,402 @@ const (
 	MinDamage         = 1  // Minimum damage on a hit
 )
 
-// combatRandom is the seeded random source for combat
-var (
-	combatRandom *rand.Rand
-	combatMu     sync.Mutex
-)
+// countingSource wraps a random source and counts how many values it has
+// produced, so a Dice can be restored to the same point after a restart
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
 
-func init() {
-	// Initialize with a default seed based on current time
-	combatRandom = rand.New(rand.NewSource(time.Now().UnixNano()))
+func (c *countingSource) Int63() int64 {
+	c.draws++
+	return c.src.Int63()
 }
 
-// SetCombatSeed sets the random seed for combat, making it reproducible
-func SetCombatSeed(seed int64) {
-	combatMu.Lock()
-	defer combatMu.Unlock()
-	combatRandom = rand.New(rand.NewSource(seed))
+func (c *countingSource) Uint64() uint64 {
+	c.draws++
+	return c.src.Uint64()
 }
 
-// Combat handles turn-based combat mechanics
+func (c *countingSource) Seed(seed int64) {
+	c.src.Seed(seed)
+	c.draws = 0
+}
 
-// RollDice simulates a dice roll (e.g., d20) using the seeded random source
-func RollDice(sides int) int {
-	combatMu.Lock()
-	defer combatMu.Unlock()
-	return combatRandom.Intn(sides) + 1
+// Dice is a seeded random source for dice rolls. Each game owns its own so
+// that concurrent games don't disturb each other's sequences, which keeps a
+// game reproducible from its seed.
+type Dice struct {
+	mu     sync.Mutex
+	seed   int64
+	source *countingSource
+	random *rand.Rand
 }
 
-// RollDamage calculates damage with dice (e.g., 2d6 + modifier)
-func RollDamage(numDice, diceSides, modifier int) int {
-	total := modifier
-	for i := 0; i < numDice; i++ {
-		total += RollDice(diceSides)
+// NewDice creates dice seeded with the given value
+func NewDice(seed int64) *Dice {
+	source := &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+	return &Dice{
+		seed:   seed,
+		source: source,
+		random: rand.New(source),
 	}
-	if total < 0 {
-		return 0
+}
+
+// RestoreDice recreates dice that have already produced draws values
+func RestoreDice(seed int64, draws uint64) *Dice {
+	d := NewDice(seed)
+	for d.source.draws < draws {
+		d.source.Int63()
 	}
-	return total
+	return d
 }
 
-// CalculateAttack performs a turn-based attack
-func CalculateAttack(attackerStrength, defenderArmor int) *CombatResult {
-	// Attack roll: d20 + strength modifier
-	attackRoll := RollDice(D20) + (attackerStrength / 2)
+// Seed returns the seed the dice were created with
+func (d *Dice) Seed() int64 {
+	return d.seed
+}
 
-	// Defense: base + armor bonus
-	defense := BaseDefense + defenderArmor
+// Draws returns how many random values the dice have produced
+func (d *Dice) Draws() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.source.draws
+}
 
-	result := &CombatResult{}
+// Roll simulates a dice roll (e.g., d20)
+func (d *Dice) Roll(sides int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.random.Intn(sides) + 1
+}
 
-	if attackRoll >= defense {
-		// Hit! Roll damage
-		damage := RollDamage(1, D6, attackerStrength/2)
-		result.DefenderDamage = damage
-		result.Message = "Hit!"
-	} else {
-		// Miss
-		result.DefenderDamage = 0
-		result.Message = "Miss!"
-	}
+// Float64 returns a random value in [0.0, 1.0)
+func (d *Dice) Float64() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.random.Float64()
+}
+
+// Intn returns a random value in [0, n)
+func (d *Dice) Intn(n int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.random.Intn(n)
+}
 
-	return result
+// NewID creates a random hex ID from the dice, so IDs are reproducible too
+func (d *Dice) NewID(prefix string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return fmt.Sprintf("%s%016x", prefix, d.random.Uint64())
 }
 
-// ExecuteCombatTurn executes one full turn of combat
+// ExecuteCombatTurn executes one full round of combat: the player acts, then
+// every monster still standing gets its attack
+// dice is the game's random source
+// monsters are the living monsters in the player's room, in the order they act
+// targets are the monsters the player's attack or ability hits; flee ignores them
+// playerAction is "attack", "cast" or "flee"; a flee ends combat when it succeeds
+// ability is the ability cast with "cast": damage abilities hit the targets,
+// other abilities act on the player while the monsters still attack
 // weaponBonus is extra damage from equipped weapon
+// weaponOnHit is the status effect the equipped weapon's hits may inflict, if any
 // armorBonus is extra defense from equipped armor
 // Returns updated combat state, enhanced result for frontend, and whether combat continues
-func ExecuteCombatTurn(player *Character, monster *Monster, playerAction string, weaponBonus int, armorBonus int) (*CombatResult, *EnhancedCombatResult, bool) {
+func ExecuteCombatTurn(dice *Dice, player *Character, monsters []*Monster, targets []*Monster, playerAction string, ability *Ability, weaponBonus int, weaponOnHit *OnHitEffect, armorBonus int) (*CombatResult, *EnhancedCombatResult, bool) {
 	result := &CombatResult{
 		AttackerHP: player.HP,
-		DefenderHP: monster.HP,
+	}
+	if len(targets) > 0 {
+		result.DefenderHP = targets[0].HP
 	}
 
 	enhanced := &EnhancedCombatResult{}
 
-	// Calculate player's damage bonus (base strength + equipped weapon)
-	playerDamageBonus := (player.Strength / 2) + weaponBonus
+	if playerAction == "flee" {
+		return executeFlee(dice, player, monsters, armorBonus, result, enhanced)
+	}
+
+	switch {
+	case player.HasEffect(EffectStun):
+		// A stunned player loses the attack (or spell, keeping its mana) but
+		// the monsters still strike
+		stunned := &AttackResult{
+			AttackerName: player.Name,
+			RemainingHP:  result.DefenderHP,
+			Stunned:      true,
+		}
+		if len(targets) > 0 {
+			stunned.TargetID = targets[0].ID
+			stunned.TargetName = targets[0].Name
+		}
+		enhanced.PlayerAttacks = append(enhanced.PlayerAttacks, stunned)
+		if playerAction == "cast" {
+			addLine(result, fmt.Sprintf("You are stunned and cannot cast %s!", ability.Name))
+		} else {
+			addLine(result, fmt.Sprintf("You are stunned and cannot attack the %s!", stunned.TargetName))
+		}
+	case playerAction == "cast":
+		executeCast(dice, player, targets, ability, result, enhanced)
+	default:
+		for _, target := range targets {
+			executeAttack(dice, player, target, weaponBonus, weaponOnHit, result, enhanced)
+		}
+	}
+
+	return monstersAttack(dice, player, monsters, targets, armorBonus, result, enhanced)
+}
+
+// addLine adds a line to a combat result's message
+func addLine(result *CombatResult, line string) {
+	if result.Message != "" {
+		result.Message += "\n"
+	}
+	result.Message += line
+}
+
+// recordDefeat marks a monster felled by the player in both results
+func recordDefeat(monster *Monster, result *CombatResult, enhanced *EnhancedCombatResult) {
+	monster.HP = 0
+	monster.IsAlive = false
+	result.DefenderDied = true
+	enhanced.EnemyDefeated = true
+	enhanced.Defeated = append(enhanced.Defeated, monster.ID)
+}
+
+// executeAttack rolls the player's weapon attack on one monster and records
+// it in both results
+func executeAttack(dice *Dice, player *Character, monster *Monster, weaponBonus int, weaponOnHit *OnHitEffect, result *CombatResult, enhanced *EnhancedCombatResult) {
+	passive := player.Passive()
+
+	// Calculate player's damage bonus (base strength + equipped weapon + class passive)
+	playerDamageBonus := (player.Strength / 2) + weaponBonus + passive.DamageBonus
 
 	// Player attacks monster
-	attackRoll := RollDice(D20) + (player.Dexterity / 2)
+	attackRoll := dice.Roll(D20) + (player.Dexterity / 2) + passive.AttackBonus
 	monsterDefense := BaseDefense
 
 	playerAttack := &AttackResult{
 		AttackerName: player.Name,
+		TargetID:     monster.ID,
 		TargetName:   monster.Name,
 	}
+	enhanced.PlayerAttacks = append(enhanced.PlayerAttacks, playerAttack)
+
+	if attackRoll < monsterDefense {
+		playerAttack.RemainingHP = monster.HP
+		result.DefenderHP = monster.HP
+		addLine(result, fmt.Sprintf("You swing at the %s but miss!", monster.Name))
+		return
+	}
+
+	// Hit! Roll damage - track the d6 roll for critical detection
+	damageRoll := dice.Roll(D6)
+	damage := damageRoll + playerDamageBonus
+	if damage < MinDamage {
+		damage = MinDamage
+	}
 
-	if attackRoll >= monsterDefense {
-		// Hit! Roll damage - track the d6 roll for critical detection
-		damageRoll := RollDice(D6)
-		damage := damageRoll + playerDamageBonus
-		if damage < MinDamage {
-			damage = MinDamage
+	playerAttack.WasHit = true
+	playerAttack.Damage = damage
+	playerAttack.WasCritical = damageRoll >= CriticalThreshold
+
+	monster.HP -= damage
+	result.DefenderDamage += damage
+
+	critical := ""
+	if playerAttack.WasCritical {
+		critical = "CRITICAL HIT! "
+	}
+
+	if monster.HP <= 0 {
+		recordDefeat(monster, result, enhanced)
+		result.DefenderHP = 0
+		playerAttack.RemainingHP = 0
+		addLine(result, fmt.Sprintf("%sYou strike the %s for %d damage! The %s collapses!",
+			critical, monster.Name, damage, monster.Name))
+		return
+	}
+
+	result.DefenderHP = monster.HP
+	playerAttack.RemainingHP = monster.HP
+	line := fmt.Sprintf("%sYou strike the %s for %d damage! (%d/%d HP)",
+		critical, monster.Name, damage, monster.HP, monster.MaxHP)
+
+	if weaponOnHit != nil && dice.Intn(100) < weaponOnHit.Chance {
+		monster.ApplyEffect(weaponOnHit.Effect)
+		playerAttack.Effect = weaponOnHit.Effect.Type
+		line += fmt.Sprintf(" The %s is %s!", monster.Name, effectDefinitions[weaponOnHit.Effect.Type].Adjective)
+	}
+	addLine(result, line)
+}
+
+// executeCast casts an ability in combat. A damage ability always hits its
+// targets; any other ability acts on the player.
+func executeCast(dice *Dice, player *Character, targets []*Monster, ability *Ability, result *CombatResult, enhanced *EnhancedCombatResult) {
+	if !ability.NeedsTarget() {
+		targets = nil
+	}
+	cast, attacks := applyAbility(dice, player, ability, targets)
+	enhanced.Cast = cast
+	enhanced.PlayerAttacks = append(enhanced.PlayerAttacks, attacks...)
+
+	for i, target := range targets {
+		result.DefenderDamage += attacks[i].Damage
+		result.DefenderHP = target.HP
+		if !target.IsAlive {
+			recordDefeat(target, result, enhanced)
 		}
+	}
+	addLine(result, describeCast(cast, player, targets, attacks))
+}
 
-		playerAttack.WasHit = true
-		playerAttack.Damage = damage
-		playerAttack.WasCritical = damageRoll >= CriticalThreshold
-
-		monster.HP -= damage
-		result.DefenderDamage = damage
-
-		if monster.HP <= 0 {
-			monster.HP = 0
-			monster.IsAlive = false
-			result.DefenderHP = 0
-			result.DefenderDied = true
-			playerAttack.RemainingHP = 0
-			enhanced.PlayerAttack = playerAttack
-			enhanced.EnemyDefeated = true
-
-			if playerAttack.WasCritical {
-				result.Message = fmt.Sprintf("CRITICAL HIT! You strike the %s for %d damage! The %s collapses!",
-					monster.Name, damage, monster.Name)
-			} else {
-				result.Message = fmt.Sprintf("You strike the %s for %d damage! The %s collapses!",
-					monster.Name, damage, monster.Name)
+// monstersAttack lets every monster still standing strike the player in
+// turn; the player's targets strike back. Returns false once the player dies
+// or no monster is left.
+func monstersAttack(dice *Dice, player *Character, monsters []*Monster, targets []*Monster, armorBonus int, result *CombatResult, enhanced *EnhancedCombatResult) (*CombatResult, *EnhancedCombatResult, bool) {
+	fighting := false
+	for _, monster := range monsters {
+		if !monster.IsAlive {
+			continue
+		}
+		fighting = true
+
+		verb := "strikes"
+		for _, target := range targets {
+			if target.ID == monster.ID {
+				verb = "strikes back"
 			}
+		}
+
+		line, alive := resolveMonsterAttack(dice, player, monster, armorBonus, verb, result, enhanced)
+		addLine(result, line)
+		if !alive {
 			return result, enhanced, false // Combat ends
 		}
+	}
+	return result, enhanced, fighting
+}
+
+// executeFlee resolves a flee attempt: the player's Dexterity roll against
+// each monster's pursuit in turn. Every monster that catches the player gets
+// a free attack, and a stunned player is caught by all of them. The roll
+// reported is the one against the first monster to catch the player, or the
+// last one evaded.
+func executeFlee(dice *Dice, player *Character, monsters []*Monster, armorBonus int, result *CombatResult, enhanced *EnhancedCombatResult) (*CombatResult, *EnhancedCombatResult, bool) {
+	enhanced.FleeAttempted = true
+	stunned := player.HasEffect(EffectStun)
+	caught := false
+
+	for _, monster := range monsters {
+		if !monster.IsAlive {
+			continue
+		}
 
-		result.DefenderHP = monster.HP
-		playerAttack.RemainingHP = monster.HP
-		if playerAttack.WasCritical {
-			result.Message = fmt.Sprintf("CRITICAL HIT! You strike the %s for %d damage! (%d/%d HP)",
-				monster.Name, damage, monster.HP, monster.MaxHP)
+		var line string
+		if stunned {
+			line = fmt.Sprintf("You are too stunned to get away from the %s!", monster.Name)
 		} else {
-			result.Message = fmt.Sprintf("You strike the %s for %d damage! (%d/%d HP)",
-				monster.Name, damage, monster.HP, monster.MaxHP)
+			roll := dice.Roll(D20) + (player.Dexterity / 2) + player.Passive().FleeBonus
+			dc := BaseDefense + (monster.Damage / 2)
+			if !caught {
+				enhanced.FleeRoll = roll
+				enhanced.FleeDC = dc
+			}
+			if roll >= dc {
+				addLine(result, fmt.Sprintf("You slip away from the %s! (DEX %d vs DC %d)", monster.Name, roll, dc))
+				continue
+			}
+			line = fmt.Sprintf("The %s cuts off your escape! (DEX %d vs DC %d)", monster.Name, roll, dc)
+		}
+
+		caught = true
+		attack, alive := resolveMonsterAttack(dice, player, monster, armorBonus, "catches you", result, enhanced)
+		addLine(result, line+" "+attack)
+		if !alive {
+			return result, enhanced, false // Combat ends
 		}
-	} else {
-		playerAttack.WasHit = false
-		playerAttack.Damage = 0
-		playerAttack.RemainingHP = monster.HP
-		result.Message = fmt.Sprintf("You swing at the %s but miss!", monster.Name)
 	}
-	enhanced.PlayerAttack = playerAttack
 
-	// Monster counter-attacks
-	monsterAttackRoll := RollDice(D20)
-	// Player defense includes dexterity and equipped armor
-	playerDefense := BaseDefense + (player.Dexterity / 2) + armorBonus
+	enhanced.Fled = !caught
+	return result, enhanced, caught
+}
 
+// resolveMonsterAttack rolls a monster's attack on the player, records it in
+// both results and describes it. verb describes a hit (e.g. "strikes back").
+// A stunned monster loses the attack instead; a hit may inflict the monster's
+// on-hit status effect. Returns false if the player died.
+func resolveMonsterAttack(dice *Dice, player *Character, monster *Monster, armorBonus int, verb string, result *CombatResult, enhanced *EnhancedCombatResult) (string, bool) {
 	enemyAttack := &AttackResult{
+		AttackerID:   monster.ID,
 		AttackerName: monster.Name,
 		TargetName:   player.Name,
+		RemainingHP:  player.HP,
 	}
+	enhanced.EnemyAttacks = append(enhanced.EnemyAttacks, enemyAttack)
 
-	if monsterAttackRoll >= playerDefense {
-		// Monster hits - roll d6 for damage variance and critical detection
-		damageRoll := RollDice(D6)
-		monsterDamage := monster.Damage + (damageRoll - 3) // -2 to +3 variance
-		if monsterDamage < MinDamage {
-			monsterDamage = MinDamage
-		}
+	if monster.ConsumeStun() {
+		enemyAttack.Stunned = true
+		return fmt.Sprintf("The %s is stunned and cannot attack!", monster.Name), true
+	}
 
-		enemyAttack.WasHit = true
-		enemyAttack.Damage = monsterDamage
-		enemyAttack.WasCritical = damageRoll >= CriticalThreshold
-
-		player.TakeDamage(monsterDamage)
-		result.AttackerDamage = monsterDamage
-		result.AttackerHP = player.HP
-		enemyAttack.RemainingHP = player.HP
-
-		if !player.IsAlive {
-			result.AttackerDied = true
-			enhanced.EnemyAttack = enemyAttack
-			enhanced.PlayerDied = true
-
-			if enemyAttack.WasCritical {
-				result.Message += fmt.Sprintf(" CRITICAL HIT! The %s strikes back for %d damage! You have fallen...",
-					monster.Name, monsterDamage)
-			} else {
-				result.Message += fmt.Sprintf(" The %s strikes back for %d damage! You have fallen...",
-					monster.Name, monsterDamage)
-			}
-			return result, enhanced, false // Combat ends
-		}
+	monsterAttackRoll := dice.Roll(D20)
+	// Player defense includes dexterity, equipped armor and the class passive
+	playerDefense := BaseDefense + (player.Dexterity / 2) + armorBonus + player.Passive().DefenseBonus
 
-		if enemyAttack.WasCritical {
-			result.Message += fmt.Sprintf(" CRITICAL HIT! The %s strikes back for %d damage! (HP: %d/%d)",
-				monster.Name, monsterDamage, player.HP, player.MaxHP)
-		} else {
-			result.Message += fmt.Sprintf(" The %s strikes back for %d damage! (HP: %d/%d)",
-				monster.Name, monsterDamage, player.HP, player.MaxHP)
-		}
-	} else {
-		enemyAttack.WasHit = false
-		enemyAttack.Damage = 0
-		enemyAttack.RemainingHP = player.HP
-		result.Message += fmt.Sprintf(" The %s tries to attack but misses!", monster.Name)
+	if monsterAttackRoll < playerDefense {
+		return fmt.Sprintf("The %s tries to attack but misses!", monster.Name), true
+	}
+
+	// Monster hits - roll d6 for damage variance and critical detection
+	damageRoll := dice.Roll(D6)
+	monsterDamage := monster.Damage + (damageRoll - 3) // -2 to +3 variance
+	if monsterDamage < MinDamage {
+		monsterDamage = MinDamage
 	}
-	enhanced.EnemyAttack = enemyAttack
 
-	return result, enhanced, true // Combat continues
+	// A ward soaks up what it can before the player is hurt
+	shielded := monsterDamage
+	monsterDamage = player.AbsorbDamage(monsterDamage)
+	enemyAttack.Absorbed = shielded - monsterDamage
+
+	enemyAttack.WasHit = true
+	enemyAttack.Damage = monsterDamage
+	enemyAttack.WasCritical = damageRoll >= CriticalThreshold
+
+	if monsterDamage == 0 {
+		return fmt.Sprintf("The %s %s, but your ward absorbs all %d damage! (shield: %d)",
+			monster.Name, verb, enemyAttack.Absorbed, player.Shield), true
+	}
+
+	player.TakeDamage(monsterDamage)
+	result.AttackerDamage += monsterDamage
+	result.AttackerHP = player.HP
+	enemyAttack.RemainingHP = player.HP
+
+	critical := ""
+	if enemyAttack.WasCritical {
+		critical = "CRITICAL HIT! "
+	}
+
+	if !player.IsAlive {
+		result.AttackerDied = true
+		enhanced.PlayerDied = true
+		return fmt.Sprintf("%sThe %s %s for %d damage! You have fallen...",
+			critical, monster.Name, verb, monsterDamage), false
+	}
+
+	line := fmt.Sprintf("%sThe %s %s for %d damage! (HP: %d/%d)",
+		critical, monster.Name, verb, monsterDamage, player.HP, player.MaxHP)
+	if enemyAttack.Absorbed > 0 {
+		line += fmt.Sprintf(" Your ward absorbed %d.", enemyAttack.Absorbed)
+	}
+
+	if onHit := monster.OnHit; onHit != nil && dice.Intn(100) < onHit.Chance {
+		player.ApplyEffect(onHit.Effect)
+		enemyAttack.Effect = onHit.Effect.Type
+		line += fmt.Sprintf(" You are %s!", effectDefinitions[onHit.Effect.Type].Adjective)
+	}
+	return line, true
 }
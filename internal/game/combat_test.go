@@ -0,0 +1,146 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// newTestMage starts a seeded game with a sturdy mage in the first room, so
+// a round of attacks can't kill them
+func newTestMage(t *testing.T) (*GameState, []*Room) {
+	t.Helper()
+	gs, rooms := newTestState(t, 2)
+	gs.Character.ApplyClass("mage", Classes["mage"])
+	gs.Character.MaxHP, gs.Character.HP = 200, 200
+	return gs, rooms
+}
+
+func TestEveryMonsterAttacks(t *testing.T) {
+	gs, rooms := newTestMage(t)
+	room := rooms[0].ID
+	target := addTestMonster(gs, "m1", room, nil)
+	target.HP = 1
+	addTestMonster(gs, "m2", room, nil)
+	stunned := addTestMonster(gs, "m3", room, nil)
+	stunned.ApplyEffect(StatusEffect{Type: EffectStun, Duration: 1})
+	addTestMonster(gs, "m4", room, nil)
+	monsters := gs.GetRoomMonsters(room)
+
+	// A firebolt never misses, so m1 falls before its turn comes round
+	ability, targets, err := gs.PrepareCast("firebolt", target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, enhanced, fighting := ExecuteCombatTurn(gs.Dice, gs.Character, monsters, targets, "cast", ability, 0, nil, 0)
+	if !fighting {
+		t.Error("combat ended with monsters still standing")
+	}
+	if len(enhanced.Defeated) != 1 || enhanced.Defeated[0] != target.ID {
+		t.Fatalf("defeated %v, want only %s", enhanced.Defeated, target.ID)
+	}
+
+	// Every monster still standing acts, in order; the stunned one loses its attack
+	want := []string{"m2", "m3", "m4"}
+	if len(enhanced.EnemyAttacks) != len(want) {
+		t.Fatalf("%d monsters attacked, want %d", len(enhanced.EnemyAttacks), len(want))
+	}
+	for i, attack := range enhanced.EnemyAttacks {
+		if attack.AttackerID != want[i] {
+			t.Errorf("attack %d made by %s, want %s", i, attack.AttackerID, want[i])
+		}
+		if wantStunned := attack.AttackerID == "m3"; attack.Stunned != wantStunned {
+			t.Errorf("%s stunned = %v, want %v", attack.AttackerID, attack.Stunned, wantStunned)
+		}
+	}
+	if stunned.HasEffect(EffectStun) {
+		t.Error("the stunned monster's stun was not used up")
+	}
+}
+
+func TestMonstersAttackUntilPlayerFalls(t *testing.T) {
+	// Find a seed where the first monster's attack kills a 1 HP player
+	for seed := int64(1); seed <= 100; seed++ {
+		gs, rooms := newTestState(t, 1)
+		gs.Dice = NewDice(seed)
+		if nextD20(gs) < BaseDefense+gs.Character.Dexterity/2 {
+			continue
+		}
+		gs.Character.HP = 1
+		addTestMonster(gs, "m1", rooms[0].ID, nil)
+		addTestMonster(gs, "m2", rooms[0].ID, nil)
+
+		result, enhanced, fighting := ExecuteCombatTurn(gs.Dice, gs.Character, gs.GetRoomMonsters(rooms[0].ID), nil, "attack", nil, 0, nil, 0)
+		if fighting || !enhanced.PlayerDied || !result.AttackerDied {
+			t.Errorf("seed %d: a killing blow left combat going %v, player died %v", seed, fighting, enhanced.PlayerDied)
+		}
+		if len(enhanced.EnemyAttacks) != 1 {
+			t.Errorf("seed %d: %d monsters attacked, want the round to stop at the killing blow", seed, len(enhanced.EnemyAttacks))
+		}
+		return
+	}
+	t.Fatal("no seed opened with a hit")
+}
+
+func TestAreaDamage(t *testing.T) {
+	gs, rooms := newTestMage(t)
+	room := rooms[0].ID
+	weak := addTestMonster(gs, "m1", room, nil)
+	weak.HP = 1
+	tough := addTestMonster(gs, "m2", room, nil)
+	tough.HP, tough.MaxHP = 50, 50
+	elsewhere := addTestMonster(gs, "m3", rooms[1].ID, nil)
+
+	// Flame wave needs no target: it takes everything in the room
+	ability, targets, err := gs.PrepareCast("flamewave", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0] != weak || targets[1] != tough {
+		t.Fatalf("flame wave targets %v, want the two monsters in the room", targets)
+	}
+
+	mana := gs.Character.Mana
+	result, enhanced, fighting := ExecuteCombatTurn(gs.Dice, gs.Character, gs.GetRoomMonsters(room), targets, "cast", ability, 0, nil, 0)
+	if !fighting {
+		t.Error("combat ended with a monster still standing")
+	}
+	if gs.Character.Mana != mana-ability.ManaCost {
+		t.Errorf("mana %d after the cast, want %d", gs.Character.Mana, mana-ability.ManaCost)
+	}
+
+	cast := enhanced.Cast
+	if cast == nil || len(cast.TargetIDs) != 2 {
+		t.Fatalf("cast %+v, want it to hit both monsters", cast)
+	}
+	if len(enhanced.PlayerAttacks) != 2 {
+		t.Fatalf("%d player attacks, want one per monster hit", len(enhanced.PlayerAttacks))
+	}
+	total := 0
+	for _, attack := range enhanced.PlayerAttacks {
+		if !attack.WasHit || attack.Damage < ability.Power+1 || attack.Damage > ability.Power+D6 {
+			t.Errorf("%s took %d damage, want %d to %d", attack.TargetID, attack.Damage, ability.Power+1, ability.Power+D6)
+		}
+		total += attack.Damage
+	}
+	if cast.Amount != total || result.DefenderDamage != total {
+		t.Errorf("cast dealt %d and the round %d in total, want the %d of its hits", cast.Amount, result.DefenderDamage, total)
+	}
+
+	if weak.IsAlive || len(enhanced.Defeated) != 1 || enhanced.Defeated[0] != weak.ID {
+		t.Errorf("defeated %v, want only %s", enhanced.Defeated, weak.ID)
+	}
+	if tough.HP != tough.MaxHP-enhanced.PlayerAttacks[1].Damage {
+		t.Errorf("%s has %d HP, want %d", tough.ID, tough.HP, tough.MaxHP-enhanced.PlayerAttacks[1].Damage)
+	}
+	if elsewhere.HP != elsewhere.MaxHP {
+		t.Error("flame wave reached a monster in another room")
+	}
+
+	// The survivor strikes back
+	if len(enhanced.EnemyAttacks) != 1 || enhanced.EnemyAttacks[0].AttackerID != tough.ID {
+		t.Errorf("enemy attacks %v, want one from %s", enhanced.EnemyAttacks, tough.ID)
+	}
+	if !strings.Contains(result.Message, "Flame Wave") {
+		t.Errorf("message %q does not name the ability", result.Message)
+	}
+}
@@ -0,0 +1,275 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status effect types
+const (
+	EffectPoison       = "poison"
+	EffectBleed        = "bleed"
+	EffectStun         = "stun"
+	EffectRegeneration = "regeneration"
+)
+
+// Status effect limits
+const (
+	MaxEffectPotency  = 5  // Highest damage or healing per turn an effect can stack to
+	MaxEffectDuration = 10 // Most turns an effect can stack to
+)
+
+// Stacking rules: what happens when an effect is applied to someone who
+// already has it
+const (
+	StackPotency  = "potency"  // Potency adds up; the longer duration wins
+	StackDuration = "duration" // Durations add up; the higher potency wins
+	StackRefresh  = "refresh"  // Nothing adds up; the higher potency and longer duration win
+)
+
+// StatusEffect is a lasting effect on a character or monster. Poison and bleed
+// deal Potency damage and regeneration heals Potency HP at the end of every
+// turn; stun makes its holder lose their next Duration combat actions.
+type StatusEffect struct {
+	Type     string `json:"type"`
+	Potency  int    `json:"potency"`
+	Duration int    `json:"duration"` // Turns left
+}
+
+// OnHitEffect is a status effect a monster's hits may inflict
+type OnHitEffect struct {
+	Chance int          `json:"chance"` // Percent chance per hit
+	Effect StatusEffect `json:"effect"`
+}
+
+// EffectTick is what one turn of status effects did to their holder
+type EffectTick struct {
+	Damage  int
+	Healing int
+	Causes  []string // Effect types that dealt the damage
+	Expired []string // Effect types that wore off
+}
+
+// effectDefinition describes how an effect type behaves
+type effectDefinition struct {
+	Stacking  string
+	Harmful   bool   // Harmful consumables are thrown at a monster instead of used on yourself
+	Adjective string // Describes the holder, e.g. "poisoned"
+}
+
+var effectDefinitions = map[string]effectDefinition{
+	EffectPoison:       {Stacking: StackPotency, Harmful: true, Adjective: "poisoned"},
+	EffectBleed:        {Stacking: StackDuration, Harmful: true, Adjective: "bleeding"},
+	EffectStun:         {Stacking: StackRefresh, Harmful: true, Adjective: "stunned"},
+	EffectRegeneration: {Stacking: StackRefresh, Adjective: "regenerating"},
+}
+
+// IsHarmfulEffect returns true if an effect type hurts its holder
+func IsHarmfulEffect(effectType string) bool {
+	return effectDefinitions[effectType].Harmful
+}
+
+// Describe returns a short description of an effect, e.g. "poisoned (2/turn, 3 turns)"
+func (e StatusEffect) Describe() string {
+	adjective := effectDefinitions[e.Type].Adjective
+	if adjective == "" {
+		adjective = e.Type
+	}
+	if e.Type == EffectStun {
+		return fmt.Sprintf("%s (%d turn(s))", adjective, e.Duration)
+	}
+	return fmt.Sprintf("%s (%d/turn, %d turn(s))", adjective, e.Potency, e.Duration)
+}
+
+// Describe returns a short description of an on-hit effect, e.g.
+// "30% chance: poisoned (1/turn, 3 turn(s))"
+func (o OnHitEffect) Describe() string {
+	return fmt.Sprintf("%d%% chance: %s", o.Chance, o.Effect.Describe())
+}
+
+// DescribeEffects joins the descriptions of a list of effects
+func DescribeEffects(effects []StatusEffect) string {
+	parts := make([]string, 0, len(effects))
+	for _, e := range effects {
+		parts = append(parts, e.Describe())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// addEffect applies an effect to a list of effects following its stacking rule
+func addEffect(effects []StatusEffect, effect StatusEffect) []StatusEffect {
+	for i := range effects {
+		existing := &effects[i]
+		if existing.Type != effect.Type {
+			continue
+		}
+		switch effectDefinitions[effect.Type].Stacking {
+		case StackPotency:
+			existing.Potency += effect.Potency
+			existing.Duration = max(existing.Duration, effect.Duration)
+		case StackDuration:
+			existing.Duration += effect.Duration
+			existing.Potency = max(existing.Potency, effect.Potency)
+		default:
+			existing.Potency = max(existing.Potency, effect.Potency)
+			existing.Duration = max(existing.Duration, effect.Duration)
+		}
+		existing.Potency = min(existing.Potency, MaxEffectPotency)
+		existing.Duration = min(existing.Duration, MaxEffectDuration)
+		return effects
+	}
+	effect.Potency = min(effect.Potency, MaxEffectPotency)
+	effect.Duration = min(effect.Duration, MaxEffectDuration)
+	return append(effects, effect)
+}
+
+// removeEffect drops an effect type from a list of effects. Returns the new
+// list and whether the effect was there.
+func removeEffect(effects []StatusEffect, effectType string) ([]StatusEffect, bool) {
+	kept := effects[:0]
+	found := false
+	for _, e := range effects {
+		if e.Type == effectType {
+			found = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, found
+}
+
+// hasEffect returns true if a list of effects includes an effect type
+func hasEffect(effects []StatusEffect, effectType string) bool {
+	for _, e := range effects {
+		if e.Type == effectType {
+			return true
+		}
+	}
+	return false
+}
+
+// consumeStun uses up one turn of a stun. Returns the new list and whether
+// its holder was stunned.
+func consumeStun(effects []StatusEffect) ([]StatusEffect, bool) {
+	for i := range effects {
+		if effects[i].Type != EffectStun {
+			continue
+		}
+		effects[i].Duration--
+		if effects[i].Duration <= 0 {
+			effects, _ = removeEffect(effects, EffectStun)
+		}
+		return effects, true
+	}
+	return effects, false
+}
+
+// tickEffects runs one turn of damage and healing effects and counts down
+// their durations. In combat stuns only count down when they cost their holder
+// an action; out of combat they wear off a turn at a time like the rest.
+func tickEffects(effects []StatusEffect, inCombat bool) ([]StatusEffect, EffectTick) {
+	var tick EffectTick
+	kept := effects[:0]
+	for _, e := range effects {
+		switch {
+		case e.Type == EffectStun && inCombat:
+			kept = append(kept, e)
+			continue
+		case e.Type == EffectStun:
+			// Lost time, but no damage or healing
+		case effectDefinitions[e.Type].Harmful:
+			tick.Damage += e.Potency
+			tick.Causes = append(tick.Causes, e.Type)
+		default:
+			tick.Healing += e.Potency
+		}
+		e.Duration--
+		if e.Duration <= 0 {
+			tick.Expired = append(tick.Expired, e.Type)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, tick
+}
+
+// ApplyEffect puts a status effect on the character
+func (c *Character) ApplyEffect(effect StatusEffect) {
+	c.Effects = addEffect(c.Effects, effect)
+}
+
+// HasEffect returns true if the character has a status effect
+func (c *Character) HasEffect(effectType string) bool {
+	return hasEffect(c.Effects, effectType)
+}
+
+// CureEffect removes a status effect from the character. Returns false if
+// the character did not have it.
+func (c *Character) CureEffect(effectType string) bool {
+	var found bool
+	c.Effects, found = removeEffect(c.Effects, effectType)
+	return found
+}
+
+// ConsumeStun uses up a turn of the character's stun. Returns true if the
+// character was stunned.
+func (c *Character) ConsumeStun() bool {
+	var stunned bool
+	c.Effects, stunned = consumeStun(c.Effects)
+	return stunned
+}
+
+// TickEffects applies a turn of the character's status effects, healing
+// before damage. Healing reports the HP actually restored. A stun wears off
+// out of combat, where there is no action for it to cost.
+func (c *Character) TickEffects(inCombat bool) EffectTick {
+	var tick EffectTick
+	c.Effects, tick = tickEffects(c.Effects, inCombat)
+	if tick.Healing > 0 {
+		oldHP := c.HP
+		c.Heal(tick.Healing)
+		tick.Healing = c.HP - oldHP
+	}
+	if tick.Damage > 0 {
+		c.TakeDamage(tick.Damage)
+	}
+	return tick
+}
+
+// ApplyEffect puts a status effect on the monster
+func (m *Monster) ApplyEffect(effect StatusEffect) {
+	m.Effects = addEffect(m.Effects, effect)
+}
+
+// HasEffect returns true if the monster has a status effect
+func (m *Monster) HasEffect(effectType string) bool {
+	return hasEffect(m.Effects, effectType)
+}
+
+// ConsumeStun uses up a turn of the monster's stun. Returns true if the
+// monster was stunned.
+func (m *Monster) ConsumeStun() bool {
+	var stunned bool
+	m.Effects, stunned = consumeStun(m.Effects)
+	return stunned
+}
+
+// TickEffects applies a turn of the monster's status effects. A monster
+// brought to 0 HP is marked dead; the caller handles its defeat.
+func (m *Monster) TickEffects() EffectTick {
+	var tick EffectTick
+	m.Effects, tick = tickEffects(m.Effects, true) // Only monsters facing the character tick
+	if tick.Healing > 0 {
+		oldHP := m.HP
+		m.HP = min(m.HP+tick.Healing, m.MaxHP)
+		tick.Healing = m.HP - oldHP
+	}
+	if tick.Damage > 0 {
+		m.HP -= tick.Damage
+		if m.HP <= 0 {
+			m.HP = 0
+			m.IsAlive = false
+		}
+	}
+	return tick
+}
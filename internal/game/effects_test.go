@@ -0,0 +1,29 @@
+package game
+
+import "testing"
+
+func TestStunWearsOffOutOfCombat(t *testing.T) {
+	char := NewCharacter("Hero")
+	char.ApplyEffect(StatusEffect{Type: EffectStun, Duration: 2})
+
+	// In combat only a lost action uses the stun up
+	char.TickEffects(true)
+	if !char.HasEffect(EffectStun) || char.Effects[0].Duration != 2 {
+		t.Fatalf("a combat turn ticked the stun: %v", char.Effects)
+	}
+	if !char.ConsumeStun() || char.Effects[0].Duration != 1 {
+		t.Fatalf("a lost combat action did not use up a turn of stun: %v", char.Effects)
+	}
+
+	// Once the fight is over it wears off by itself
+	tick := char.TickEffects(false)
+	if char.HasEffect(EffectStun) {
+		t.Errorf("stun outlasted its duration out of combat: %v", char.Effects)
+	}
+	if len(tick.Expired) != 1 || tick.Expired[0] != EffectStun {
+		t.Errorf("expired effects = %v, want [stun]", tick.Expired)
+	}
+	if tick.Damage != 0 || tick.Healing != 0 {
+		t.Errorf("stun dealt %d damage and %d healing", tick.Damage, tick.Healing)
+	}
+}
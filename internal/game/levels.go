@@ -0,0 +1,111 @@
+package game
+
+import "fmt"
+
+// Dungeon depth constants
+const (
+	DefaultFinalDepth = 3  // Levels in a run unless new_game asks otherwise
+	MaxFinalDepth     = 10 // Deepest run new_game allows
+)
+
+// IsFinalDepth returns true if the current level is the last one, so its
+// exit leaves the dungeon. Games without a final depth have a single level.
+func (gs *GameState) IsFinalDepth() bool {
+	if gs.Dungeon == nil || gs.FinalDepth <= 0 {
+		return true
+	}
+	return gs.Dungeon.Depth >= gs.FinalDepth
+}
+
+// CanDescend returns true if the character is standing on stairs leading to
+// a deeper level
+func (gs *GameState) CanDescend() bool {
+	room := gs.GetCurrentRoom()
+	return room != nil && room.IsExit && !gs.IsFinalDepth()
+}
+
+// EnterLevel replaces the current level with a newly generated one. Rooms,
+// monsters, traps and anything left on the floor stay behind; the character
+// keeps their inventory. The caller places the character and populates the
+// level afterwards.
+func (gs *GameState) EnterLevel(dungeon *Dungeon, rooms []*Room, connections []*RoomConnection) error {
+	if dungeon == nil {
+		return fmt.Errorf("no dungeon")
+	}
+
+	for id, item := range gs.Items {
+		if item.CharacterID == nil {
+			delete(gs.Items, id)
+		}
+	}
+	gs.ItemsByRoom = make(map[string]map[string]bool)
+
+	gs.Dungeon = dungeon
+	gs.Rooms = make(map[string]*Room)
+	gs.RoomsByCoord = make(map[string]*Room)
+	gs.Connections = make(map[string][]*RoomConnection)
+	gs.Monsters = make(map[string]*Monster)
+	gs.MonstersByRoom = make(map[string]map[string]bool)
+	gs.Traps = make(map[string]*Trap)
+	gs.Merchants = make(map[string]*Merchant)
+	gs.VisitedRooms = make(map[string]bool)
+	gs.RevealedRooms = make(map[string]bool)
+	gs.PreviousRoomID = ""
+
+	for _, room := range rooms {
+		gs.AddRoom(room)
+	}
+	for _, conn := range connections {
+		gs.AddConnection(conn)
+	}
+	gs.RoomsGenerated += len(rooms)
+	return nil
+}
+
+// GetEntrance returns the room the current level starts in
+func (gs *GameState) GetEntrance() *Room {
+	for _, room := range gs.Rooms {
+		if room.IsEntrance {
+			return room
+		}
+	}
+	return nil
+}
+
+// GetExit returns the room holding the current level's exit or stairs
+func (gs *GameState) GetExit() *Room {
+	for _, room := range gs.Rooms {
+		if room.IsExit {
+			return room
+		}
+	}
+	return nil
+}
+
+// DistanceFromEntrance returns how many grid steps a room is from the
+// entrance of its level
+func (gs *GameState) DistanceFromEntrance(room *Room) int {
+	entrance := gs.GetEntrance()
+	if room == nil || entrance == nil {
+		return 0
+	}
+	return abs(room.X-entrance.X) + abs(room.Y-entrance.Y)
+}
+
+// LevelProgress returns how far a room is from the entrance as a fraction of
+// the exit's distance: 0 at the entrance, 1 at the exit
+func (gs *GameState) LevelProgress(room *Room) float64 {
+	exitDistance := gs.DistanceFromEntrance(gs.GetExit())
+	if exitDistance == 0 {
+		return 0
+	}
+	return float64(gs.DistanceFromEntrance(room)) / float64(exitDistance)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// addTestMonster puts a live monster with a loot table in a room
+func addTestMonster(gs *GameState, id, roomID string, loot []LootEntry) *Monster {
+	monster := &Monster{ID: id, Name: "Goblin", HP: 8, MaxHP: 8, Damage: 2, RoomID: roomID, IsAlive: true, LootTable: loot}
+	gs.AddMonster(monster)
+	return monster
+}
+
+func TestKillMonsterDropsLoot(t *testing.T) {
+	gs, rooms := newTestState(t, 2)
+	sword := &Item{ID: "template", Name: "Short Sword", Type: "weapon", Damage: 5, IsEquipped: true}
+	monster := addTestMonster(gs, "goblin", rooms[1].ID, []LootEntry{{Weight: 1, Item: sword}})
+
+	drops := gs.KillMonster(monster.ID)
+	if monster.IsAlive || monster.HP != 0 || gs.Kills != 1 {
+		t.Errorf("after the kill: alive %v, HP %d, kills %d", monster.IsAlive, monster.HP, gs.Kills)
+	}
+	if len(drops) != 1 {
+		t.Fatalf("dropped %d items, want the only entry in the table", len(drops))
+	}
+
+	drop := drops[0]
+	if drop.ID == sword.ID || gs.Items[drop.ID] != drop {
+		t.Errorf("drop %q was not added under a fresh ID", drop.ID)
+	}
+	if drop.RoomID == nil || *drop.RoomID != monster.RoomID || drop.CharacterID != nil || drop.IsEquipped {
+		t.Errorf("drop is in room %v, held by %v, equipped %v; want loose on the floor of %s",
+			drop.RoomID, drop.CharacterID, drop.IsEquipped, monster.RoomID)
+	}
+	if drop.Name != sword.Name || drop.Damage != sword.Damage {
+		t.Errorf("drop is %s with damage %d, want a copy of the %s", drop.Name, drop.Damage, sword.Name)
+	}
+	if !sword.IsEquipped || sword.RoomID != nil {
+		t.Error("the drop changed the loot table's template")
+	}
+	if len(gs.TurnContext.NewItems) != 1 || gs.TurnContext.NewItems[0] != drop.ID {
+		t.Errorf("new items this turn = %v, want the drop", gs.TurnContext.NewItems)
+	}
+}
+
+func TestKillMonsterWithoutLoot(t *testing.T) {
+	gs, rooms := newTestState(t, 2)
+	tables := map[string][]LootEntry{
+		"empty table":  nil,
+		"nothing only": {{Weight: 1}},
+		"zero weights": {{Weight: 0, Item: &Item{Name: "Gold Coins", Type: "treasure", Value: 10}}},
+	}
+	for name, table := range tables {
+		monster := addTestMonster(gs, name, rooms[1].ID, table)
+		if drops := gs.KillMonster(monster.ID); len(drops) != 0 {
+			t.Errorf("%s: dropped %v", name, drops)
+		}
+	}
+	if items := gs.GetRoomItems(rooms[1].ID); len(items) != 0 {
+		t.Errorf("%d items on the floor after lootless kills", len(items))
+	}
+}
+
+func TestLootRollsEveryEntry(t *testing.T) {
+	gs, rooms := newTestState(t, 2)
+	table := []LootEntry{
+		{Weight: 2},
+		{Weight: 1, Item: &Item{Name: "Health Potion", Type: "consumable", Healing: 10}},
+		{Weight: 1, Item: &Item{Name: "Gold Coins", Type: "treasure", Value: 10}},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		monster := addTestMonster(gs, fmt.Sprintf("goblin-%d", i), rooms[1].ID, table)
+		drops := gs.KillMonster(monster.ID)
+		switch len(drops) {
+		case 0:
+			counts["nothing"]++
+		case 1:
+			counts[drops[0].Name]++
+		default:
+			t.Fatalf("one kill dropped %d items", len(drops))
+		}
+	}
+	for _, outcome := range []string{"nothing", "Health Potion", "Gold Coins"} {
+		if counts[outcome] == 0 {
+			t.Errorf("200 kills never rolled %s: %v", outcome, counts)
+		}
+	}
+	if counts["nothing"] < counts["Health Potion"] || counts["nothing"] < counts["Gold Coins"] {
+		t.Errorf("the double-weighted empty entry came up least often: %v", counts)
+	}
+}
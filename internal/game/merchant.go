@@ -0,0 +1,199 @@
+package game
+
+import "fmt"
+
+// Pricing constants. An item's price is the worth of its stats, scaled by
+// its rarity; merchants buy back at a fraction of what they sell for.
+const (
+	PricePerDamage      = 8  // Gold per point of weapon damage
+	PricePerArmor       = 8  // Gold per point of armor
+	PricePerHealing     = 2  // Gold per HP a consumable heals
+	PricePerCure        = 10 // Gold for curing a status effect
+	PricePerEffectPoint = 4  // Gold per potency per turn of a status effect
+	PricePerStunTurn    = 20 // Gold per turn of stun
+	PricePerOnHitChance = 1  // Gold per percent chance of an on-hit effect
+	MinItemPrice        = 5  // Cheapest anything sells for
+	SellPricePct        = 50 // Percent of its price a merchant pays for an item
+)
+
+// rarityPricePct scales an item's price by its rarity
+var rarityPricePct = map[string]int{
+	"common":    100,
+	"uncommon":  150,
+	"rare":      250,
+	"legendary": 400,
+}
+
+// effectWorth prices a status effect by its strength and length
+func effectWorth(e StatusEffect) int {
+	if e.Type == EffectStun {
+		return e.Duration * PricePerStunTurn
+	}
+	return e.Potency * e.Duration * PricePerEffectPoint
+}
+
+// BuyPrice returns the gold a merchant asks for an item
+func BuyPrice(item *Item) int {
+	worth := item.Damage*PricePerDamage + item.Armor*PricePerArmor + item.Healing*PricePerHealing
+	if item.Cures != "" {
+		worth += PricePerCure
+	}
+	if item.Effect != nil {
+		worth += effectWorth(*item.Effect)
+	}
+	if item.OnHit != nil {
+		worth += item.OnHit.Chance * PricePerOnHitChance
+	}
+
+	pct, ok := rarityPricePct[item.Rarity]
+	if !ok {
+		pct = 100
+	}
+	return max(worth*pct/100, MinItemPrice)
+}
+
+// SellPrice returns the gold a merchant pays for an item
+func SellPrice(item *Item) int {
+	return max(BuyPrice(item)*SellPricePct/100, 1)
+}
+
+// IsTradeable returns true if merchants deal in an item. Keys belong to the
+// dungeon's doors, and treasure is already gold.
+func IsTradeable(item *Item) bool {
+	return item.Type != "key" && item.Type != "treasure"
+}
+
+// GetRoomMerchant returns the merchant in a room, or nil if there is none
+func (gs *GameState) GetRoomMerchant(roomID string) *Merchant {
+	for _, merchant := range gs.Merchants {
+		if merchant.RoomID == roomID {
+			return merchant
+		}
+	}
+	return nil
+}
+
+// tradingMerchant returns the merchant the character can trade with in their
+// current room
+func (gs *GameState) tradingMerchant() (*Merchant, error) {
+	if gs.Character == nil {
+		return nil, fmt.Errorf("no character")
+	}
+	merchant := gs.GetRoomMerchant(gs.Character.CurrentRoomID)
+	if merchant == nil {
+		return nil, fmt.Errorf("there is no merchant here")
+	}
+	if gs.HasMonstersInRoom(merchant.RoomID) {
+		return nil, fmt.Errorf("the %s won't trade while monsters are about", merchant.Name)
+	}
+	return merchant, nil
+}
+
+// CheckBuy returns why the character can't buy an item, or nil if BuyItem
+// would succeed
+func (gs *GameState) CheckBuy(itemID string) error {
+	_, _, err := gs.stockItem(itemID)
+	return err
+}
+
+// stockItem finds an item the character can afford and carry in the stock of
+// the merchant in their room, returning the merchant and its stock index
+func (gs *GameState) stockItem(itemID string) (*Merchant, int, error) {
+	merchant, err := gs.tradingMerchant()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	index := -1
+	for i, item := range merchant.Stock {
+		if item.ID == itemID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, 0, fmt.Errorf("the %s doesn't sell that", merchant.Name)
+	}
+
+	item := merchant.Stock[index]
+	if price := BuyPrice(item); gs.Character.Gold < price {
+		return nil, 0, fmt.Errorf("the %s costs %d gold - you only have %d", item.Name, price, gs.Character.Gold)
+	}
+	if gs.FreeSlots() <= 0 {
+		return nil, 0, fmt.Errorf("your pack is full (%d/%d) - drop or sell something first",
+			gs.InventorySize(), gs.Character.CarryCapacity())
+	}
+	return merchant, index, nil
+}
+
+// BuyItem buys an item from the merchant in the character's room, paying its
+// price in gold. Returns the item and the price paid.
+func (gs *GameState) BuyItem(itemID string) (*Item, int, error) {
+	merchant, index, err := gs.stockItem(itemID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	item := merchant.Stock[index]
+	price := BuyPrice(item)
+	merchant.Stock = append(merchant.Stock[:index], merchant.Stock[index+1:]...)
+	gs.Character.Gold -= price
+	item.RoomID = nil
+	item.CharacterID = &gs.Character.ID
+	item.IsEquipped = false
+	gs.AddItem(item)
+
+	return item, price, nil
+}
+
+// CheckSell returns why the character can't sell an item, or nil if SellItem
+// would succeed
+func (gs *GameState) CheckSell(itemID string) error {
+	_, _, err := gs.saleItem(itemID)
+	return err
+}
+
+// saleItem finds an item in the character's inventory that the merchant in
+// their room will buy
+func (gs *GameState) saleItem(itemID string) (*Merchant, *Item, error) {
+	merchant, err := gs.tradingMerchant()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item, err := gs.inventoryItem(itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !IsTradeable(item) {
+		return nil, nil, fmt.Errorf("the %s has no interest in the %s", merchant.Name, item.Name)
+	}
+	return merchant, item, nil
+}
+
+// SellItem sells an item from the character's inventory to the merchant in
+// their room, taking it off first if it is equipped. The merchant adds it to
+// their stock. Returns the item and the gold received.
+func (gs *GameState) SellItem(itemID string) (*Item, int, error) {
+	merchant, item, err := gs.saleItem(itemID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if item.IsEquipped {
+		if err := gs.Character.UnequipItem(item); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	price := SellPrice(item)
+	if gs.ItemsByChar[gs.Character.ID] != nil {
+		delete(gs.ItemsByChar[gs.Character.ID], itemID)
+	}
+	delete(gs.Items, itemID)
+	item.CharacterID = nil
+	merchant.Stock = append(merchant.Stock, item)
+	gs.Character.Gold += price
+
+	return item, price, nil
+}
@@ -0,0 +1,54 @@
+package game
+
+// Scoring constants
+const (
+	ScorePerDepth       = 100 // Points per dungeon level reached
+	ScorePerKill        = 10  // Points per monster defeated
+	ScorePerGold        = 1   // Points per gold collected
+	ScorePerExploredPct = 2   // Points per percent of rooms visited
+	VictoryBonus        = 500 // Bonus for escaping the dungeon
+	TurnBonusLimit      = 200 // Victories in fewer turns than this earn the difference
+)
+
+// ExplorationPct returns the percentage of rooms visited across every level
+// of the run so far
+func (gs *GameState) ExplorationPct() float64 {
+	if gs.RoomsGenerated == 0 {
+		return 0
+	}
+	return float64(gs.RoomsVisited) / float64(gs.RoomsGenerated) * 100
+}
+
+// CalculateScore scores the run so far from depth, kills, gold collected,
+// turns and exploration. Escaping adds a victory bonus plus a bonus for speed.
+// Gold spent at or earned from merchants doesn't count.
+func (gs *GameState) CalculateScore() *Score {
+	score := &Score{
+		Kills:       gs.Kills,
+		Gold:        gs.GoldCollected,
+		Turns:       gs.TurnNumber,
+		Exploration: gs.ExplorationPct(),
+		Victory:     gs.Victory,
+	}
+	if gs.Dungeon != nil {
+		score.Depth = gs.Dungeon.Depth
+	}
+	score.Total = score.Depth*ScorePerDepth +
+		score.Kills*ScorePerKill +
+		score.Gold*ScorePerGold +
+		int(score.Exploration)*ScorePerExploredPct
+	if score.Victory {
+		score.Total += VictoryBonus
+		if score.Turns < TurnBonusLimit {
+			score.Total += TurnBonusLimit - score.Turns
+		}
+	}
+	return score
+}
+
+// finishRun records the final score once the game is over
+func (gs *GameState) finishRun() {
+	if gs.Score == nil {
+		gs.Score = gs.CalculateScore()
+	}
+}
@@ -0,0 +1,50 @@
+package game
+
+import "testing"
+
+func TestExplorationSpansLevels(t *testing.T) {
+	gs := NewGameState()
+	gs.Character = NewCharacter("Hero")
+
+	// Every room of a four-room first level, then one room of ten below it
+	dungeon, rooms := testLevel(1, 4)
+	if err := gs.EnterLevel(dungeon, rooms, nil); err != nil {
+		t.Fatalf("entering level 1: %v", err)
+	}
+	for _, room := range rooms {
+		gs.MarkRoomVisited(room.ID)
+	}
+	gs.MarkRoomVisited(rooms[0].ID) // Revisits don't count again
+
+	dungeon, rooms = testLevel(2, 10)
+	if err := gs.EnterLevel(dungeon, rooms, nil); err != nil {
+		t.Fatalf("entering level 2: %v", err)
+	}
+	gs.MarkRoomVisited(rooms[0].ID)
+
+	if gs.RoomsVisited != 5 || gs.RoomsGenerated != 14 {
+		t.Errorf("rooms visited %d of %d, want 5 of 14", gs.RoomsVisited, gs.RoomsGenerated)
+	}
+	want := 5.0 / 14 * 100
+	if got := gs.ExplorationPct(); got != want {
+		t.Errorf("exploration = %.2f%%, want %.2f%% over both levels", got, want)
+	}
+	if got := gs.CalculateScore().Exploration; got != want {
+		t.Errorf("score exploration = %.2f%%, want %.2f%%", got, want)
+	}
+}
+
+func TestScoreCountsGoldCollected(t *testing.T) {
+	gs, rooms := newTestState(t, 1)
+	treasure := &Item{ID: "treasure", Name: "Gold Coins", Type: "treasure", Value: 25, RoomID: &rooms[0].ID}
+	gs.AddItem(treasure)
+
+	if err := gs.TakeItem(treasure.ID); err != nil {
+		t.Fatalf("take treasure: %v", err)
+	}
+	gs.Character.Gold -= 20 // Spent at a merchant
+
+	if got := gs.CalculateScore().Gold; got != 25 {
+		t.Errorf("score gold = %d, want the 25 collected", got)
+	}
+}
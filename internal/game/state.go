@@ -1,7 +1,9 @@
 package game
 
 import (
+	"crypto/rand"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +18,7 @@ type TurnContext struct {
 	ConsecutiveCombat int
 	DefeatedMonsters  []string // Monster IDs defeated this turn
 	NewItems          []string // Item IDs discovered this turn
+	ArrivedMonsters   []string // Monster IDs that wandered into the character's room this turn
 }
 
 // GameState holds all in-memory game state
@@ -32,10 +35,23 @@ type GameState struct {
 	ItemsByRoom    map[string]map[string]bool   // room ID -> item IDs (for O(1) lookup)
 	ItemsByChar    map[string]map[string]bool   // character ID -> item IDs (for O(1) lookup)
 	Traps          map[string]*Trap             // keyed by trap ID
+	Merchants      map[string]*Merchant         // keyed by merchant ID
 	VisitedRooms   map[string]bool              // keyed by room ID
+	RevealedRooms  map[string]bool              // keyed by room ID, rooms shown by the reveal ability
 	GameOver       bool
 	Victory        bool
+	TurnNumber     int    // turns taken since new_game
+	PreviousRoomID string // room the character last came from, for fleeing
+	FinalDepth     int    // depth whose exit escapes the dungeon
+	Kills          int    // monsters defeated on every level so far
+	GoldCollected  int    // treasure picked up so far; spending gold doesn't lower it
+	RoomsVisited   int    // rooms visited on every level so far
+	RoomsGenerated int    // rooms generated on every level so far
+	Score          *Score // final score, set when the game ends
 	TurnContext    *TurnContext
+	Dice           *Dice          // seeded random source for everything after generation
+	IDPrefix       string         // shared prefix of every ID in this game
+	ActionLog      []ActionRecord // accepted tool calls since new_game, for replay
 }
 
 // Lock acquires a write lock on the game state
@@ -60,19 +76,69 @@ func (gs *GameState) RUnlock() {
 
 // NewGameState creates an empty game state
 func NewGameState() *GameState {
-	return &GameState{
-		Rooms:          make(map[string]*Room),
-		RoomsByCoord:   make(map[string]*Room),
-		Connections:    make(map[string][]*RoomConnection),
-		Monsters:       make(map[string]*Monster),
-		MonstersByRoom: make(map[string]map[string]bool),
-		Items:          make(map[string]*Item),
-		ItemsByRoom:    make(map[string]map[string]bool),
-		ItemsByChar:    make(map[string]map[string]bool),
-		Traps:          make(map[string]*Trap),
-		VisitedRooms:   make(map[string]bool),
-		TurnContext:    &TurnContext{},
+	gs := &GameState{}
+	gs.Reset()
+	return gs
+}
+
+// Reset clears the game state in place so a new game can start without
+// replacing the GameState (and its lock) that other callers hold.
+// The caller must hold the write lock.
+func (gs *GameState) Reset() {
+	gs.Character = nil
+	gs.Dungeon = nil
+	gs.Rooms = make(map[string]*Room)
+	gs.RoomsByCoord = make(map[string]*Room)
+	gs.Connections = make(map[string][]*RoomConnection)
+	gs.Monsters = make(map[string]*Monster)
+	gs.MonstersByRoom = make(map[string]map[string]bool)
+	gs.Items = make(map[string]*Item)
+	gs.ItemsByRoom = make(map[string]map[string]bool)
+	gs.ItemsByChar = make(map[string]map[string]bool)
+	gs.Traps = make(map[string]*Trap)
+	gs.Merchants = make(map[string]*Merchant)
+	gs.VisitedRooms = make(map[string]bool)
+	gs.RevealedRooms = make(map[string]bool)
+	gs.GameOver = false
+	gs.Victory = false
+	gs.TurnNumber = 0
+	gs.PreviousRoomID = ""
+	gs.FinalDepth = 0
+	gs.Kills = 0
+	gs.GoldCollected = 0
+	gs.RoomsVisited = 0
+	gs.RoomsGenerated = 0
+	gs.Score = nil
+	gs.TurnContext = &TurnContext{}
+	gs.Dice = nil
+	gs.IDPrefix = ""
+	gs.ActionLog = nil
+}
+
+// NewIDPrefix creates a random prefix for a game's IDs. Games generated from
+// the same seed differ only in this prefix, so their rows never collide.
+func NewIDPrefix() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// NewID creates an ID for an entity created during play. IDs come from the
+// game's dice so that replaying a game reproduces them.
+func (gs *GameState) NewID() string {
+	if gs.Dice == nil {
+		return generateID()
 	}
+	return gs.Dice.NewID(gs.IDPrefix)
+}
+
+// RecordAction appends an accepted tool call to the action log
+func (gs *GameState) RecordAction(tool string, arguments map[string]interface{}) {
+	args := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		args[k] = v
+	}
+	gs.ActionLog = append(gs.ActionLog, ActionRecord{Tool: tool, Arguments: args})
 }
 
 // ResetTurnContext clears the turn context at the start of each action
@@ -101,11 +167,38 @@ func (gs *GameState) RecordItemUsed(itemID string) {
 	gs.TurnContext.InventoryDelta.Used = append(gs.TurnContext.InventoryDelta.Used, itemID)
 }
 
+// RecordItemRemoved tracks an item the character put down this turn
+func (gs *GameState) RecordItemRemoved(itemID string) {
+	if gs.TurnContext.InventoryDelta == nil {
+		gs.TurnContext.InventoryDelta = &InventoryDelta{}
+	}
+	gs.TurnContext.InventoryDelta.Removed = append(gs.TurnContext.InventoryDelta.Removed, itemID)
+}
+
+// RecordItemDropped tracks an item dropped into the room (e.g. monster loot)
+func (gs *GameState) RecordItemDropped(itemID string) {
+	if gs.TurnContext.InventoryDelta == nil {
+		gs.TurnContext.InventoryDelta = &InventoryDelta{}
+	}
+	gs.TurnContext.InventoryDelta.Dropped = append(gs.TurnContext.InventoryDelta.Dropped, itemID)
+	gs.TurnContext.NewItems = append(gs.TurnContext.NewItems, itemID)
+}
+
 // RecordMonsterDefeated tracks a defeated monster
 func (gs *GameState) RecordMonsterDefeated(monsterID string) {
 	gs.TurnContext.DefeatedMonsters = append(gs.TurnContext.DefeatedMonsters, monsterID)
 }
 
+// RecordMonsterArrived tracks a monster that wandered into the character's room
+func (gs *GameState) RecordMonsterArrived(monsterID string) {
+	gs.TurnContext.ArrivedMonsters = append(gs.TurnContext.ArrivedMonsters, monsterID)
+}
+
+// IncrementTurn advances the game's turn counter
+func (gs *GameState) IncrementTurn() {
+	gs.TurnNumber++
+}
+
 // IncrementTurnsInRoom increments the turns spent in current room
 func (gs *GameState) IncrementTurnsInRoom() {
 	gs.TurnContext.TurnsInRoom++
@@ -158,6 +251,97 @@ func (gs *GameState) GetRoomExits(roomID string) map[string]string {
 	return exits
 }
 
+// GetExitConnection returns the connection leaving a room in a direction
+func (gs *GameState) GetExitConnection(roomID, direction string) *RoomConnection {
+	for _, conn := range gs.Connections[roomID] {
+		if conn.Direction == direction {
+			return conn
+		}
+	}
+	return nil
+}
+
+// GetLockedExits returns a room's locked exit directions in a stable order
+func (gs *GameState) GetLockedExits(roomID string) []string {
+	locked := make([]string, 0)
+	for _, dir := range gs.GetExitDirections(roomID) {
+		if conn := gs.GetExitConnection(roomID, dir); conn != nil && conn.IsLocked {
+			locked = append(locked, dir)
+		}
+	}
+	return locked
+}
+
+// HasItem returns true if the character is carrying an item
+func (gs *GameState) HasItem(itemID string) bool {
+	if gs.Character == nil || itemID == "" {
+		return false
+	}
+	item, ok := gs.Items[itemID]
+	return ok && item.CharacterID != nil && *item.CharacterID == gs.Character.ID
+}
+
+// CheckUnlock returns why the door in a direction can't be unlocked, or nil
+// if UnlockDoor would succeed
+func (gs *GameState) CheckUnlock(direction string) error {
+	if gs.Character == nil {
+		return fmt.Errorf("no character")
+	}
+
+	conn := gs.GetExitConnection(gs.Character.CurrentRoomID, direction)
+	if conn == nil {
+		return fmt.Errorf("there is no door to the %s", direction)
+	}
+	if !conn.IsLocked {
+		return fmt.Errorf("the door to the %s is not locked", direction)
+	}
+	if !gs.HasItem(conn.KeyID) {
+		return fmt.Errorf("you don't have the key for this door")
+	}
+	return nil
+}
+
+// UnlockDoor unlocks the door in a direction from the character's room using
+// the carried key, which is used up. Both sides of the door are unlocked.
+// Returns the key that was used.
+func (gs *GameState) UnlockDoor(direction string) (*Item, error) {
+	if err := gs.CheckUnlock(direction); err != nil {
+		return nil, err
+	}
+
+	roomID := gs.Character.CurrentRoomID
+	conn := gs.GetExitConnection(roomID, direction)
+	key := gs.Items[conn.KeyID]
+	conn.IsLocked = false
+	for _, back := range gs.Connections[conn.ConnectedRoomID] {
+		if back.ConnectedRoomID == roomID {
+			back.IsLocked = false
+		}
+	}
+
+	delete(gs.ItemsByChar[gs.Character.ID], key.ID)
+	delete(gs.Items, key.ID)
+	gs.RecordItemUsed(key.ID)
+
+	return key, nil
+}
+
+// directionOrder fixes the order exits are listed in
+var directionOrder = map[string]int{"north": 0, "south": 1, "east": 2, "west": 3}
+
+// GetExitDirections returns a room's exit directions in a stable order
+func (gs *GameState) GetExitDirections(roomID string) []string {
+	exits := gs.GetRoomExits(roomID)
+	dirs := make([]string, 0, len(exits))
+	for dir := range exits {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return directionOrder[dirs[i]] < directionOrder[dirs[j]]
+	})
+	return dirs
+}
+
 // GetRoomMonsters returns all alive monsters in a room (O(1) lookup via index)
 func (gs *GameState) GetRoomMonsters(roomID string) []*Monster {
 	monsters := make([]*Monster, 0)
@@ -170,6 +354,8 @@ func (gs *GameState) GetRoomMonsters(roomID string) []*Monster {
 			monsters = append(monsters, m)
 		}
 	}
+	// Stable order so combat and snapshots are reproducible
+	sort.Slice(monsters, func(i, j int) bool { return monsters[i].ID < monsters[j].ID })
 	return monsters
 }
 
@@ -185,9 +371,15 @@ func (gs *GameState) GetRoomItems(roomID string) []*Item {
 			items = append(items, item)
 		}
 	}
+	sortItems(items)
 	return items
 }
 
+// sortItems orders items by ID so listings are stable
+func sortItems(items []*Item) {
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+}
+
 // GetRoomTraps returns all traps in a room
 func (gs *GameState) GetRoomTraps(roomID string) []*Trap {
 	traps := make([]*Trap, 0)
@@ -196,6 +388,7 @@ func (gs *GameState) GetRoomTraps(roomID string) []*Trap {
 			traps = append(traps, trap)
 		}
 	}
+	sort.Slice(traps, func(i, j int) bool { return traps[i].ID < traps[j].ID })
 	return traps
 }
 
@@ -214,6 +407,7 @@ func (gs *GameState) GetInventory() []*Item {
 			items = append(items, item)
 		}
 	}
+	sortItems(items)
 	return items
 }
 
@@ -222,8 +416,9 @@ func (gs *GameState) HasMonstersInRoom(roomID string) bool {
 	return len(gs.GetRoomMonsters(roomID)) > 0
 }
 
-// MoveCharacter moves the character in a direction
-func (gs *GameState) MoveCharacter(direction string) error {
+// CheckMove returns why the character can't move in a direction, or nil if
+// MoveCharacter would succeed
+func (gs *GameState) CheckMove(direction string) error {
 	if gs.Character == nil {
 		return fmt.Errorf("no character")
 	}
@@ -239,28 +434,75 @@ func (gs *GameState) MoveCharacter(direction string) error {
 	}
 
 	// Find the exit in the given direction
-	exits := gs.GetRoomExits(currentRoomID)
-	newRoomID, ok := exits[direction]
-	if !ok {
+	if _, ok := gs.GetRoomExits(currentRoomID)[direction]; !ok {
 		return fmt.Errorf("cannot move %s - no exit in that direction", direction)
 	}
 
-	// Move the character
-	gs.Character.CurrentRoomID = newRoomID
-	gs.VisitedRooms[newRoomID] = true
+	// Locked doors open only for the matching key
+	if conn := gs.GetExitConnection(currentRoomID, direction); conn != nil && conn.IsLocked && !gs.HasItem(conn.KeyID) {
+		return fmt.Errorf("the door to the %s is locked - find its key", direction)
+	}
+	return nil
+}
 
-	// Check for victory
-	newRoom := gs.Rooms[newRoomID]
-	if newRoom != nil && newRoom.IsExit {
-		gs.Victory = true
-		gs.GameOver = true
+// MoveCharacter moves the character in a direction
+func (gs *GameState) MoveCharacter(direction string) error {
+	if err := gs.CheckMove(direction); err != nil {
+		return err
+	}
+
+	currentRoomID := gs.Character.CurrentRoomID
+	newRoomID := gs.GetRoomExits(currentRoomID)[direction]
+
+	// A carried key opens a locked door on the way through
+	if conn := gs.GetExitConnection(currentRoomID, direction); conn != nil && conn.IsLocked {
+		if _, err := gs.UnlockDoor(direction); err != nil {
+			return err
+		}
 	}
 
+	gs.enterRoom(newRoomID)
 	return nil
 }
 
-// TakeItem moves an item from the room to the character's inventory
-func (gs *GameState) TakeItem(itemID string) error {
+// Retreat moves the character back to the room they came from. Unlike
+// MoveCharacter it ignores monsters in the room, so callers must have
+// already decided the character got away.
+func (gs *GameState) Retreat() (string, error) {
+	if gs.Character == nil {
+		return "", fmt.Errorf("no character")
+	}
+	if !gs.Character.IsAlive {
+		return "", fmt.Errorf("character is dead")
+	}
+
+	previousRoomID := gs.PreviousRoomID
+	if _, ok := gs.Rooms[previousRoomID]; !ok {
+		return "", fmt.Errorf("there is nowhere to retreat to")
+	}
+
+	gs.enterRoom(previousRoomID)
+	return previousRoomID, nil
+}
+
+// enterRoom moves the character into a room, remembering the room they left
+func (gs *GameState) enterRoom(roomID string) {
+	gs.PreviousRoomID = gs.Character.CurrentRoomID
+	gs.Character.CurrentRoomID = roomID
+	gs.MarkRoomVisited(roomID)
+
+	// The exit of the deepest level leaves the dungeon; the others are stairs
+	newRoom := gs.Rooms[roomID]
+	if newRoom != nil && newRoom.IsExit && gs.IsFinalDepth() {
+		gs.Victory = true
+		gs.GameOver = true
+		gs.finishRun()
+	}
+}
+
+// CheckTake returns why the character can't take an item, or nil if
+// TakeItem would succeed
+func (gs *GameState) CheckTake(itemID string) error {
 	if gs.Character == nil {
 		return fmt.Errorf("no character")
 	}
@@ -280,11 +522,35 @@ func (gs *GameState) TakeItem(itemID string) error {
 		return fmt.Errorf("item is already being carried")
 	}
 
+	// Treasure never takes up a slot, everything else needs room in the pack
+	if item.Type != "treasure" && gs.FreeSlots() <= 0 {
+		return fmt.Errorf("your pack is full (%d/%d) - drop something first",
+			gs.InventorySize(), gs.Character.CarryCapacity())
+	}
+	return nil
+}
+
+// TakeItem moves an item from the room to the character's inventory.
+// Treasure is added to the character's gold instead.
+func (gs *GameState) TakeItem(itemID string) error {
+	if err := gs.CheckTake(itemID); err != nil {
+		return err
+	}
+	item := gs.Items[itemID]
+
 	// Update indexes: remove from room, add to character
 	oldRoomID := *item.RoomID
 	if gs.ItemsByRoom[oldRoomID] != nil {
 		delete(gs.ItemsByRoom[oldRoomID], itemID)
 	}
+
+	// Treasure is converted straight to gold rather than carried
+	if item.Type == "treasure" {
+		gs.Character.Gold += item.Value
+		gs.GoldCollected += item.Value
+		delete(gs.Items, itemID)
+		return nil
+	}
 	if gs.ItemsByChar[gs.Character.ID] == nil {
 		gs.ItemsByChar[gs.Character.ID] = make(map[string]bool)
 	}
@@ -297,36 +563,142 @@ func (gs *GameState) TakeItem(itemID string) error {
 	return nil
 }
 
-// UseItem uses a consumable item from inventory
-func (gs *GameState) UseItem(itemID string) (string, error) {
+// CheckDrop returns why the character can't drop an item, or nil if
+// DropItem would succeed
+func (gs *GameState) CheckDrop(itemID string) error {
+	_, err := gs.inventoryItem(itemID)
+	return err
+}
+
+// DropItem moves an item from the character's inventory to the floor of the
+// current room, taking it off first if it is equipped
+func (gs *GameState) DropItem(itemID string) error {
+	item, err := gs.inventoryItem(itemID)
+	if err != nil {
+		return err
+	}
+
+	if item.IsEquipped {
+		if err := gs.Character.UnequipItem(item); err != nil {
+			return err
+		}
+	}
+
+	// Update indexes: remove from character, add to room
+	if gs.ItemsByChar[gs.Character.ID] != nil {
+		delete(gs.ItemsByChar[gs.Character.ID], itemID)
+	}
+	roomID := gs.Character.CurrentRoomID
+	if gs.ItemsByRoom[roomID] == nil {
+		gs.ItemsByRoom[roomID] = make(map[string]bool)
+	}
+	gs.ItemsByRoom[roomID][itemID] = true
+
+	item.CharacterID = nil
+	item.RoomID = &roomID
+	return nil
+}
+
+// UnequipItem takes off an equipped weapon or armor, leaving it in the
+// character's inventory
+func (gs *GameState) UnequipItem(itemID string) error {
+	item, err := gs.inventoryItem(itemID)
+	if err != nil {
+		return err
+	}
+	return gs.Character.UnequipItem(item)
+}
+
+// inventoryItem looks up an item the character is carrying
+func (gs *GameState) inventoryItem(itemID string) (*Item, error) {
 	if gs.Character == nil {
-		return "", fmt.Errorf("no character")
+		return nil, fmt.Errorf("no character")
 	}
 
 	item, ok := gs.Items[itemID]
 	if !ok {
-		return "", fmt.Errorf("item not found")
+		return nil, fmt.Errorf("item not found")
 	}
-
-	// Check item is in inventory
 	if item.CharacterID == nil || *item.CharacterID != gs.Character.ID {
-		return "", fmt.Errorf("item is not in your inventory")
+		return nil, fmt.Errorf("item is not in your inventory")
+	}
+	return item, nil
+}
+
+// InventorySize returns the number of items the character is carrying
+func (gs *GameState) InventorySize() int {
+	if gs.Character == nil {
+		return 0
+	}
+	count := 0
+	for itemID := range gs.ItemsByChar[gs.Character.ID] {
+		if _, ok := gs.Items[itemID]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// FreeSlots returns how many more items the character can pick up
+func (gs *GameState) FreeSlots() int {
+	if gs.Character == nil {
+		return 0
+	}
+	return max(gs.Character.CarryCapacity()-gs.InventorySize(), 0)
+}
+
+// CheckUse returns why the character can't use an item, or nil if UseItem
+// would succeed
+func (gs *GameState) CheckUse(itemID, targetID string) error {
+	item, err := gs.inventoryItem(itemID)
+	if err != nil {
+		return err
 	}
 
 	if item.Type != "consumable" {
-		return "", fmt.Errorf("cannot use this item - it's not consumable")
+		return fmt.Errorf("cannot use this item - it's not consumable")
+	}
+
+	// Harmful consumables need a monster to be thrown at
+	if item.Effect != nil && IsHarmfulEffect(item.Effect.Type) {
+		if _, err := gs.targetMonster(targetID, "throw it at"); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// UseItem uses a consumable item from inventory
+func (gs *GameState) UseItem(itemID, targetID string) (string, error) {
+	if err := gs.CheckUse(itemID, targetID); err != nil {
+		return "", err
+	}
+	item := gs.Items[itemID]
 
-	// Apply effects
+	// Apply effects. Harmful consumables are thrown at a monster instead.
 	var message string
-	if item.Healing > 0 {
-		oldHP := gs.Character.HP
-		gs.Character.Heal(item.Healing)
-		healed := gs.Character.HP - oldHP
-		message = fmt.Sprintf("You drink the %s and recover %d HP! (HP: %d/%d)",
-			item.Name, healed, gs.Character.HP, gs.Character.MaxHP)
+	if item.Effect != nil && IsHarmfulEffect(item.Effect.Type) {
+		target, _ := gs.targetMonster(targetID, "throw it at")
+		target.ApplyEffect(*item.Effect)
+		message = fmt.Sprintf("You throw the %s at the %s. It is %s!",
+			item.Name, target.Name, item.Effect.Describe())
 	} else {
-		message = fmt.Sprintf("You use the %s.", item.Name)
+		char := gs.Character
+		oldHP := char.HP
+		cured := item.Cures != "" && char.HasEffect(item.Cures)
+		char.UseConsumable(item)
+		if item.Healing > 0 {
+			message = fmt.Sprintf("You drink the %s and recover %d HP! (HP: %d/%d)",
+				item.Name, char.HP-oldHP, char.HP, char.MaxHP)
+		} else {
+			message = fmt.Sprintf("You use the %s.", item.Name)
+		}
+		if cured {
+			message += fmt.Sprintf(" You are no longer %s.", effectDefinitions[item.Cures].Adjective)
+		}
+		if item.Effect != nil {
+			message += fmt.Sprintf(" You are %s.", item.Effect.Describe())
+		}
 	}
 
 	// Remove from character index
@@ -340,7 +712,30 @@ func (gs *GameState) UseItem(itemID string) (string, error) {
 	return message, nil
 }
 
-// KillMonster marks a monster as dead and potentially drops loot
+// targetMonster finds the living monster in the character's room that a
+// thrown item or ability is aimed at. With no target given, the only monster
+// present is chosen. action completes the error messages, e.g. "throw it at".
+func (gs *GameState) targetMonster(targetID, action string) (*Monster, error) {
+	monsters := gs.GetRoomMonsters(gs.Character.CurrentRoomID)
+	if len(monsters) == 0 {
+		return nil, fmt.Errorf("there is nothing here to %s", action)
+	}
+	if targetID == "" {
+		if len(monsters) > 1 {
+			return nil, fmt.Errorf("choose a monster to %s with target_id", action)
+		}
+		return monsters[0], nil
+	}
+	for _, m := range monsters {
+		if m.ID == targetID {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("that monster is not in this room")
+}
+
+// KillMonster marks a monster as dead and rolls its loot table, placing any
+// drop on the floor of its room. Returns the dropped items.
 func (gs *GameState) KillMonster(monsterID string) []*Item {
 	monster, ok := gs.Monsters[monsterID]
 	if !ok {
@@ -349,8 +744,42 @@ func (gs *GameState) KillMonster(monsterID string) []*Item {
 
 	monster.IsAlive = false
 	monster.HP = 0
+	gs.Kills++
 
-	// Drop loot (for now, no loot drops - items are pre-placed)
+	entry := gs.rollLoot(monster.LootTable)
+	if entry == nil || entry.Item == nil {
+		return nil
+	}
+
+	drop := *entry.Item
+	drop.ID = gs.NewID()
+	roomID := monster.RoomID
+	drop.RoomID = &roomID
+	drop.CharacterID = nil
+	drop.IsEquipped = false
+	gs.AddItem(&drop)
+	gs.RecordItemDropped(drop.ID)
+
+	return []*Item{&drop}
+}
+
+// rollLoot picks one entry from a weighted loot table
+func (gs *GameState) rollLoot(table []LootEntry) *LootEntry {
+	total := 0
+	for _, entry := range table {
+		total += entry.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	roll := gs.Dice.Intn(total)
+	for i := range table {
+		roll -= table[i].Weight
+		if roll < 0 {
+			return &table[i]
+		}
+	}
 	return nil
 }
 
@@ -365,6 +794,7 @@ func (gs *GameState) KillCharacter() {
 	gs.Character.DiedAt = &now
 	gs.GameOver = true
 	gs.Victory = false
+	gs.finishRun()
 }
 
 // AddRoom adds a room to the game state
@@ -412,9 +842,17 @@ func (gs *GameState) AddTrap(trap *Trap) {
 	gs.Traps[trap.ID] = trap
 }
 
-// MarkRoomVisited marks a room as visited
+// AddMerchant adds a merchant to the game state
+func (gs *GameState) AddMerchant(merchant *Merchant) {
+	gs.Merchants[merchant.ID] = merchant
+}
+
+// MarkRoomVisited marks a room as visited, counting it the first time
 func (gs *GameState) MarkRoomVisited(roomID string) {
-	gs.VisitedRooms[roomID] = true
+	if !gs.VisitedRooms[roomID] {
+		gs.VisitedRooms[roomID] = true
+		gs.RoomsVisited++
+	}
 }
 
 // IsRoomVisited returns true if a room has been visited
@@ -422,6 +860,11 @@ func (gs *GameState) IsRoomVisited(roomID string) bool {
 	return gs.VisitedRooms[roomID]
 }
 
+// IsRoomRevealed returns true if a room has been revealed by an ability
+func (gs *GameState) IsRoomRevealed(roomID string) bool {
+	return gs.RevealedRooms[roomID]
+}
+
 // GetRoomAt returns the room at the given coordinates
 func (gs *GameState) GetRoomAt(x, y int) *Room {
 	return gs.RoomsByCoord[fmt.Sprintf("%d,%d", x, y)]
@@ -458,46 +901,49 @@ func (gs *GameState) IsRoomAdjacent(roomID string) bool {
 }
 
 // RenderMap generates a box-drawing map of the dungeon
-func (gs *GameState) RenderMap(gridSize int) string {
+func (gs *GameState) RenderMap() string {
 	currentRoom := gs.GetCurrentRoom()
-	if currentRoom == nil {
+	if currentRoom == nil || gs.Dungeon == nil {
 		return "No map available"
 	}
+	width, height := gs.Dungeon.Width, gs.Dungeon.Height
 
 	var sb strings.Builder
 
 	// Top border
 	sb.WriteString("┌")
-	for x := 0; x < gridSize; x++ {
+	for x := 0; x < width; x++ {
 		sb.WriteString("───")
-		if x < gridSize-1 {
+		if x < width-1 {
 			sb.WriteString("┬")
 		}
 	}
 	sb.WriteString("┐\n")
 
-	// Rows (from top to bottom, so y goes from gridSize-1 to 0)
-	for y := gridSize - 1; y >= 0; y-- {
+	// Rows (from top to bottom, so y goes from height-1 to 0)
+	for y := height - 1; y >= 0; y-- {
 		// Room row
 		sb.WriteString("│")
-		for x := 0; x < gridSize; x++ {
+		for x := 0; x < width; x++ {
 			room := gs.GetRoomAt(x, y)
 			cell := "   " // Unknown
 
 			if room != nil {
 				if room.ID == currentRoom.ID {
 					cell = " @ " // Current location
-				} else if room.IsExit && gs.IsRoomVisited(room.ID) {
+				} else if room.IsExit && (gs.IsRoomVisited(room.ID) || gs.IsRoomRevealed(room.ID)) {
 					cell = " E " // Exit (discovered)
 				} else if gs.IsRoomVisited(room.ID) {
 					cell = " # " // Explored
 				} else if gs.IsRoomAdjacent(room.ID) {
 					cell = " ? " // Adjacent/accessible
+				} else if gs.IsRoomRevealed(room.ID) {
+					cell = " . " // Revealed but not yet reached
 				}
 			}
 
 			sb.WriteString(cell)
-			if x < gridSize-1 {
+			if x < width-1 {
 				sb.WriteString("│")
 			}
 		}
@@ -506,9 +952,9 @@ func (gs *GameState) RenderMap(gridSize int) string {
 		// Row separator or bottom border
 		if y > 0 {
 			sb.WriteString("├")
-			for x := 0; x < gridSize; x++ {
+			for x := 0; x < width; x++ {
 				sb.WriteString("───")
-				if x < gridSize-1 {
+				if x < width-1 {
 					sb.WriteString("┼")
 				}
 			}
@@ -518,16 +964,16 @@ func (gs *GameState) RenderMap(gridSize int) string {
 
 	// Bottom border
 	sb.WriteString("└")
-	for x := 0; x < gridSize; x++ {
+	for x := 0; x < width; x++ {
 		sb.WriteString("───")
-		if x < gridSize-1 {
+		if x < width-1 {
 			sb.WriteString("┴")
 		}
 	}
 	sb.WriteString("┘\n")
 
 	// Legend
-	sb.WriteString("\n@ = You  # = Explored  ? = Adjacent  E = Exit")
+	sb.WriteString("\n@ = You  # = Explored  ? = Adjacent  . = Revealed  E = Exit")
 
 	return sb.String()
 }
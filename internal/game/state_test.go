@@ -0,0 +1,45 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testLevel builds a dungeon level of n rooms in a row, the first the entrance
+func testLevel(depth, n int) (*Dungeon, []*Room) {
+	dungeon := &Dungeon{ID: fmt.Sprintf("d%d", depth), Depth: depth, Width: n, Height: 1}
+	rooms := make([]*Room, n)
+	for i := range rooms {
+		rooms[i] = &Room{ID: fmt.Sprintf("d%d-r%d", depth, i), DungeonID: dungeon.ID, X: i, IsEntrance: i == 0}
+	}
+	return dungeon, rooms
+}
+
+// newTestState starts a seeded game on a level of n rooms in a row, joined
+// east to west, with the character in the first
+func newTestState(t *testing.T, n int) (*GameState, []*Room) {
+	t.Helper()
+	gs := NewGameState()
+	gs.Dice = NewDice(42)
+	gs.Character = NewCharacter("Hero")
+
+	dungeon, rooms := testLevel(1, n)
+	connections := make([]*RoomConnection, 0, 2*(n-1))
+	for i := 1; i < n; i++ {
+		west, east := rooms[i-1].ID, rooms[i].ID
+		connections = append(connections,
+			&RoomConnection{ID: west + "-east", RoomID: west, Direction: "east", ConnectedRoomID: east},
+			&RoomConnection{ID: east + "-west", RoomID: east, Direction: "west", ConnectedRoomID: west})
+	}
+	if err := gs.EnterLevel(dungeon, rooms, connections); err != nil {
+		t.Fatalf("entering level: %v", err)
+	}
+	gs.Character.CurrentRoomID = rooms[0].ID
+	gs.MarkRoomVisited(rooms[0].ID)
+	return gs, rooms
+}
+
+// nextD20 returns what the game's next d20 will roll, without rolling it
+func nextD20(gs *GameState) int {
+	return RestoreDice(gs.Dice.Seed(), gs.Dice.Draws()).Roll(D20)
+}
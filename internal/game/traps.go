@@ -0,0 +1,162 @@
+package game
+
+import "fmt"
+
+// Trap constants
+const (
+	TrapAwareBonus     = 5 // Save bonus against a trap you have already found
+	DisarmBacklashDiff = 5 // Failing a disarm by this much or more springs the trap
+)
+
+// dexterityModifier is the bonus a character's Dexterity adds to d20 checks
+func dexterityModifier(c *Character) int {
+	return c.Dexterity / 2
+}
+
+// IsArmed returns true if the trap can still go off
+func (t *Trap) IsArmed() bool {
+	return !t.IsTriggered
+}
+
+// trapCheck rolls a d20 + Dexterity check against a trap's difficulty
+func (gs *GameState) trapCheck(trap *Trap, bonus int) *TrapResult {
+	roll := gs.Dice.Roll(D20) + dexterityModifier(gs.Character) + bonus
+	return &TrapResult{
+		TrapID:   trap.ID,
+		TrapName: trap.Name,
+		Roll:     roll,
+		DC:       trap.Difficulty,
+		Success:  roll >= trap.Difficulty,
+	}
+}
+
+// springTrap sets a trap off on the character. The trap is spent afterwards.
+func (gs *GameState) springTrap(trap *Trap, result *TrapResult) {
+	trap.IsTriggered = true
+	trap.IsDiscovered = true
+	result.Damage = trap.Damage
+	gs.Character.TakeDamage(trap.Damage)
+	if !gs.Character.IsAlive {
+		gs.KillCharacter()
+	}
+}
+
+// SpringTraps checks every armed trap in the character's room, called when the
+// character enters it. Each trap allows a Dexterity save (d20 + DEX/2 against
+// the trap's difficulty, easier if the trap was already found). A failed save
+// springs the trap; a successful one reveals it but leaves it armed.
+func (gs *GameState) SpringTraps() []*TrapResult {
+	if gs.Character == nil || !gs.Character.IsAlive {
+		return nil
+	}
+
+	results := make([]*TrapResult, 0)
+	for _, trap := range gs.GetRoomTraps(gs.Character.CurrentRoomID) {
+		if !trap.IsArmed() {
+			continue
+		}
+
+		bonus := 0
+		if trap.IsDiscovered {
+			bonus = TrapAwareBonus
+		}
+		result := gs.trapCheck(trap, bonus)
+		if result.Success {
+			trap.IsDiscovered = true
+		} else {
+			gs.springTrap(trap, result)
+		}
+		results = append(results, result)
+
+		if !gs.Character.IsAlive {
+			break
+		}
+	}
+	return results
+}
+
+// SearchForTraps looks for hidden armed traps in a room. Each makes a
+// Dexterity check against the trap's difficulty; found traps are revealed.
+func (gs *GameState) SearchForTraps(roomID string) []*TrapResult {
+	results := make([]*TrapResult, 0)
+	for _, trap := range gs.GetRoomTraps(roomID) {
+		if !trap.IsArmed() || trap.IsDiscovered {
+			continue
+		}
+		result := gs.trapCheck(trap, 0)
+		if result.Success {
+			trap.IsDiscovered = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// CheckDisarm returns why the character can't try to disarm a trap, or nil
+// if DisarmTrap would make the attempt
+func (gs *GameState) CheckDisarm(trapID string) error {
+	if gs.Character == nil {
+		return fmt.Errorf("no character")
+	}
+
+	trap, ok := gs.Traps[trapID]
+	if !ok || !trap.IsDiscovered {
+		return fmt.Errorf("trap not found - use 'search' to look for traps")
+	}
+	if !trap.IsArmed() {
+		return fmt.Errorf("that trap has already been sprung")
+	}
+	if !gs.isWithinReach(trap.RoomID) {
+		return fmt.Errorf("that trap is out of reach")
+	}
+	return nil
+}
+
+// DisarmTrap attempts to disarm a discovered trap in the character's room or
+// in a room through one of its exits. A successful Dexterity check removes
+// the trap; failing badly springs it on the character.
+func (gs *GameState) DisarmTrap(trapID string) (*TrapResult, error) {
+	if err := gs.CheckDisarm(trapID); err != nil {
+		return nil, err
+	}
+	trap := gs.Traps[trapID]
+
+	result := gs.trapCheck(trap, 0)
+	if result.Success {
+		gs.RemoveTrap(trapID)
+	} else if trap.Difficulty-result.Roll >= DisarmBacklashDiff {
+		gs.springTrap(trap, result)
+	}
+	return result, nil
+}
+
+// isWithinReach returns true for the character's room and the rooms
+// through its exits
+func (gs *GameState) isWithinReach(roomID string) bool {
+	currentRoomID := gs.Character.CurrentRoomID
+	if roomID == currentRoomID {
+		return true
+	}
+	for _, exitRoomID := range gs.GetRoomExits(currentRoomID) {
+		if exitRoomID == roomID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveTrap removes a trap from the game
+func (gs *GameState) RemoveTrap(trapID string) {
+	delete(gs.Traps, trapID)
+}
+
+// GetDiscoveredTraps returns the armed traps in a room that the player knows about
+func (gs *GameState) GetDiscoveredTraps(roomID string) []*Trap {
+	traps := make([]*Trap, 0)
+	for _, trap := range gs.GetRoomTraps(roomID) {
+		if trap.IsDiscovered && trap.IsArmed() {
+			traps = append(traps, trap)
+		}
+	}
+	return traps
+}
@@ -0,0 +1,121 @@
+package game
+
+import "testing"
+
+// addTestTrap puts an armed trap of the given difficulty in a room
+func addTestTrap(gs *GameState, id, roomID string, difficulty int, discovered bool) *Trap {
+	trap := &Trap{ID: id, RoomID: roomID, Name: "Dart Trap", Damage: 4, Difficulty: difficulty, IsDiscovered: discovered}
+	gs.AddTrap(trap)
+	return trap
+}
+
+func TestSearchForTrapsAgainstDC(t *testing.T) {
+	gs, rooms := newTestState(t, 2)
+	easy := addTestTrap(gs, "easy", rooms[0].ID, 1, false)
+	hard := addTestTrap(gs, "hard", rooms[0].ID, 100, false)
+
+	results := gs.SearchForTraps(rooms[0].ID)
+	if len(results) != 2 {
+		t.Fatalf("search rolled %d checks, want one per hidden trap", len(results))
+	}
+	modifier := dexterityModifier(gs.Character)
+	for _, result := range results {
+		if result.Roll < 1+modifier || result.Roll > D20+modifier {
+			t.Errorf("%s: roll %d is not d20 + %d", result.TrapID, result.Roll, modifier)
+		}
+		if result.DC != gs.Traps[result.TrapID].Difficulty {
+			t.Errorf("%s: DC %d, want the trap's difficulty %d", result.TrapID, result.DC, gs.Traps[result.TrapID].Difficulty)
+		}
+	}
+	if !easy.IsDiscovered || hard.IsDiscovered {
+		t.Errorf("discovered easy %v and hard %v, want only the easy trap found", easy.IsDiscovered, hard.IsDiscovered)
+	}
+
+	// Found traps aren't searched for again
+	if results := gs.SearchForTraps(rooms[0].ID); len(results) != 1 || results[0].TrapID != "hard" {
+		t.Errorf("second search checked %v, want only the hidden trap", results)
+	}
+}
+
+func TestSpringTrapsAwareBonus(t *testing.T) {
+	gs, rooms := newTestState(t, 2)
+	// Exactly beaten with the bonus for knowing the trap is there
+	dc := nextD20(gs) + dexterityModifier(gs.Character) + TrapAwareBonus
+	known := addTestTrap(gs, "known", rooms[0].ID, dc, true)
+
+	results := gs.SpringTraps()
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("save against a known trap: %+v, want a success at DC %d", results, dc)
+	}
+	if known.IsTriggered || gs.Character.HP != gs.Character.MaxHP {
+		t.Errorf("a saved trap went off: triggered %v, HP %d", known.IsTriggered, gs.Character.HP)
+	}
+
+	// The same roll without the bonus fails and springs the trap
+	gs.RemoveTrap(known.ID)
+	dc = nextD20(gs) + dexterityModifier(gs.Character) + TrapAwareBonus
+	hidden := addTestTrap(gs, "hidden", rooms[0].ID, dc, false)
+	results = gs.SpringTraps()
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("save against a hidden trap: %+v, want a failure at DC %d", results, dc)
+	}
+	if !hidden.IsTriggered || !hidden.IsDiscovered || results[0].Damage != hidden.Damage {
+		t.Errorf("failed save: triggered %v, discovered %v, damage %d; want the trap sprung for %d",
+			hidden.IsTriggered, hidden.IsDiscovered, results[0].Damage, hidden.Damage)
+	}
+	if gs.Character.HP != gs.Character.MaxHP-hidden.Damage {
+		t.Errorf("HP %d after a %d damage trap, want %d", gs.Character.HP, hidden.Damage, gs.Character.MaxHP-hidden.Damage)
+	}
+}
+
+func TestDisarmTrap(t *testing.T) {
+	modifier := dexterityModifier(NewCharacter("Hero"))
+	tests := []struct {
+		name      string
+		dc        func(roll int) int // Trap difficulty given the coming d20 check
+		success   bool
+		triggered bool
+	}{
+		{"beats the DC", func(roll int) int { return roll }, true, false},
+		{"misses narrowly", func(roll int) int { return roll + DisarmBacklashDiff - 1 }, false, false},
+		{"misses badly", func(roll int) int { return roll + DisarmBacklashDiff }, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs, rooms := newTestState(t, 2)
+			trap := addTestTrap(gs, "trap", rooms[0].ID, tt.dc(nextD20(gs)+modifier), true)
+
+			result, err := gs.DisarmTrap(trap.ID)
+			if err != nil {
+				t.Fatalf("disarm: %v", err)
+			}
+			if result.Success != tt.success || trap.IsTriggered != tt.triggered {
+				t.Errorf("roll %d against DC %d: success %v, triggered %v; want %v, %v",
+					result.Roll, result.DC, result.Success, trap.IsTriggered, tt.success, tt.triggered)
+			}
+			if _, kept := gs.Traps[trap.ID]; kept == tt.success {
+				t.Errorf("trap kept %v after a disarm with success %v", kept, tt.success)
+			}
+		})
+	}
+}
+
+func TestCheckDisarm(t *testing.T) {
+	gs, rooms := newTestState(t, 3)
+	addTestTrap(gs, "hidden", rooms[0].ID, 10, false)
+	addTestTrap(gs, "next door", rooms[1].ID, 10, true)
+	addTestTrap(gs, "far", rooms[2].ID, 10, true)
+	addTestTrap(gs, "sprung", rooms[0].ID, 10, true).IsTriggered = true
+
+	for id, wantErr := range map[string]bool{
+		"hidden":    true,
+		"next door": false, // Through an exit is within reach
+		"far":       true,
+		"sprung":    true,
+		"missing":   true,
+	} {
+		if err := gs.CheckDisarm(id); (err != nil) != wantErr {
+			t.Errorf("CheckDisarm(%q) = %v, want an error: %v", id, err, wantErr)
+		}
+	}
+}
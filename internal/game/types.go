@@ -4,18 +4,27 @@ import "time"
 
 // Character represents a player character
 type Character struct {
-	ID              string     `json:"id"`
-	Name            string     `json:"name"`
-	HP              int        `json:"hp"`
-	MaxHP           int        `json:"max_hp"`
-	Strength        int        `json:"strength"`
-	Dexterity       int        `json:"dexterity"`
-	CurrentRoomID   string     `json:"current_room_id"`
-	IsAlive         bool       `json:"is_alive"`
-	CreatedAt       time.Time  `json:"created_at"`
-	DiedAt          *time.Time `json:"died_at,omitempty"`
-	EquippedWeaponID *string   `json:"equipped_weapon_id,omitempty"`
-	EquippedArmorID  *string   `json:"equipped_armor_id,omitempty"`
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	Class            string         `json:"class"`
+	HP               int            `json:"hp"`
+	MaxHP            int            `json:"max_hp"`
+	Strength         int            `json:"strength"`
+	Dexterity        int            `json:"dexterity"`
+	CurrentRoomID    string         `json:"current_room_id"`
+	IsAlive          bool           `json:"is_alive"`
+	CreatedAt        time.Time      `json:"created_at"`
+	DiedAt           *time.Time     `json:"died_at,omitempty"`
+	EquippedWeaponID *string        `json:"equipped_weapon_id,omitempty"`
+	EquippedArmorID  *string        `json:"equipped_armor_id,omitempty"`
+	Gold             int            `json:"gold"`
+	Level            int            `json:"level"`
+	XP               int            `json:"xp"`
+	StatPoints       int            `json:"stat_points"` // Level-up stat increases not yet chosen
+	Mana             int            `json:"mana"`
+	MaxMana          int            `json:"max_mana"`
+	Shield           int            `json:"shield,omitempty"` // Damage a ward will absorb from monster attacks
+	Effects          []StatusEffect `json:"effects,omitempty"`
 }
 
 // Room represents a location in the dungeon
@@ -33,44 +42,60 @@ type Room struct {
 
 // RoomConnection represents a connection between rooms
 type RoomConnection struct {
-	ID               string `json:"id"`
-	RoomID           string `json:"room_id"`
-	Direction        string `json:"direction"`
-	ConnectedRoomID  string `json:"connected_room_id"`
+	ID              string `json:"id"`
+	RoomID          string `json:"room_id"`
+	Direction       string `json:"direction"`
+	ConnectedRoomID string `json:"connected_room_id"`
+	IsLocked        bool   `json:"is_locked"`
+	KeyID           string `json:"key_id,omitempty"` // Key item that opens this door
 }
 
 // Monster represents an enemy
 type Monster struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	HP          int       `json:"hp"`
-	MaxHP       int       `json:"max_hp"`
-	Damage      int       `json:"damage"`
-	RoomID      string    `json:"room_id"`
-	IsAlive     bool      `json:"is_alive"`
-	LootTable   []string  `json:"loot_table"` // Item IDs that can drop
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	HP          int            `json:"hp"`
+	MaxHP       int            `json:"max_hp"`
+	Damage      int            `json:"damage"`
+	RoomID      string         `json:"room_id"`
+	IsAlive     bool           `json:"is_alive"`
+	LootTable   []LootEntry    `json:"loot_table"`       // Weighted drops rolled on death
+	OnHit       *OnHitEffect   `json:"on_hit,omitempty"` // Status effect its hits may inflict
+	Effects     []StatusEffect `json:"effects,omitempty"`
+}
+
+// LootEntry is one weighted outcome of a monster's loot table. An entry with
+// no item is the chance of dropping nothing.
+type LootEntry struct {
+	Weight int   `json:"weight"`
+	Item   *Item `json:"item,omitempty"` // Template for the drop; ID and location are set when it drops
 }
 
 // Item represents an object that can be picked up
 type Item struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Type        string  `json:"type"` // weapon, armor, consumable, key, treasure
-	Damage      int     `json:"damage"`
-	Armor       int     `json:"armor"`
-	Healing     int     `json:"healing"`
-	Rarity      string  `json:"rarity"` // common, uncommon, rare, legendary
-	RoomID      *string `json:"room_id,omitempty"`
-	CharacterID *string `json:"character_id,omitempty"`
-	IsEquipped  bool    `json:"is_equipped"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Type        string        `json:"type"` // weapon, armor, consumable, key, treasure
+	Damage      int           `json:"damage"`
+	Armor       int           `json:"armor"`
+	Healing     int           `json:"healing"`
+	Rarity      string        `json:"rarity"` // common, uncommon, rare, legendary
+	Value       int           `json:"value"`  // Gold a treasure is worth; merchants price other items from their stats
+	RoomID      *string       `json:"room_id,omitempty"`
+	CharacterID *string       `json:"character_id,omitempty"`
+	IsEquipped  bool          `json:"is_equipped"`
+	Effect      *StatusEffect `json:"effect,omitempty"` // Status effect a consumable applies
+	Cures       string        `json:"cures,omitempty"`  // Status effect a consumable removes
+	OnHit       *OnHitEffect  `json:"on_hit,omitempty"` // Status effect a weapon's hits may inflict
 }
 
 // Trap represents a hazard in a room
 type Trap struct {
 	ID           string `json:"id"`
 	RoomID       string `json:"room_id"`
+	Name         string `json:"name"`
 	Description  string `json:"description"`
 	Damage       int    `json:"damage"`
 	IsTriggered  bool   `json:"is_triggered"`
@@ -78,6 +103,27 @@ type Trap struct {
 	Difficulty   int    `json:"difficulty"`
 }
 
+// Merchant is a trader set up in a room, selling a stock of items and buying
+// the character's
+type Merchant struct {
+	ID          string  `json:"id"`
+	RoomID      string  `json:"room_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Stock       []*Item `json:"stock"` // Items for sale, including anything sold to the merchant
+}
+
+// Score is the breakdown of a finished run's score
+type Score struct {
+	Total       int     `json:"total"`
+	Depth       int     `json:"depth"`       // Deepest level reached
+	Kills       int     `json:"kills"`       // Monsters defeated
+	Gold        int     `json:"gold"`        // Gold collected
+	Turns       int     `json:"turns"`       // Turns taken
+	Exploration float64 `json:"exploration"` // Percent of rooms visited
+	Victory     bool    `json:"victory"`
+}
+
 // GameEvent represents an event in the game for UI generation
 type GameEvent struct {
 	ID          int       `json:"id"`
@@ -93,6 +139,8 @@ type Dungeon struct {
 	ID        string    `json:"id"`
 	Seed      int64     `json:"seed"`
 	Depth     int       `json:"depth"`
+	Width     int       `json:"width"`  // Grid columns
+	Height    int       `json:"height"` // Grid rows
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -109,27 +157,61 @@ type CombatResult struct {
 
 // EventInfo provides structured metadata about the last game event
 type EventInfo struct {
-	Type     string   `json:"type"`     // combat, discovery, movement, interaction, death, victory
-	Subtype  string   `json:"subtype"`  // attack_hit, attack_miss, enemy_defeated, item_found, etc.
-	Entities []string `json:"entities"` // IDs of involved monsters/items
+	Type     string      `json:"type"`           // combat, discovery, movement, interaction, death, victory
+	Subtype  string      `json:"subtype"`        // attack_hit, attack_miss, enemy_defeated, item_found, etc.
+	Entities []string    `json:"entities"`       // IDs of involved monsters/items
+	Trap     *TrapResult `json:"trap,omitempty"` // Trap check behind a trap event
+	Cast     *CastResult `json:"cast,omitempty"` // Ability behind a cast event
+}
+
+// TrapResult represents the outcome of a trap check: a save against a trap
+// springing, a search, or a disarm attempt
+type TrapResult struct {
+	TrapID   string `json:"trapId"`
+	TrapName string `json:"trapName"`
+	Roll     int    `json:"roll"`    // d20 + dexterity modifier
+	DC       int    `json:"dc"`      // Trap difficulty
+	Success  bool   `json:"success"` // Save made, trap found, or trap disarmed
+	Damage   int    `json:"damage"`  // Damage taken if the trap went off
+}
+
+// CastResult represents the outcome of casting an ability
+type CastResult struct {
+	Ability   string   `json:"ability"`
+	Kind      string   `json:"kind"`                // damage, heal, shield, reveal
+	TargetIDs []string `json:"targetIds,omitempty"` // Monsters a damaging ability hit
+	Amount    int      `json:"amount"`              // Damage dealt in total, HP healed, shield gained or rooms revealed
+	ManaCost  int      `json:"manaCost"`
+	ManaLeft  int      `json:"manaLeft"`
 }
 
 // AttackResult represents the detailed outcome of a single attack
 type AttackResult struct {
+	AttackerID   string `json:"attackerId,omitempty"` // Monster making an enemy attack
 	AttackerName string `json:"attackerName"`
+	TargetID     string `json:"targetId,omitempty"` // Monster hit by a player attack
 	TargetName   string `json:"targetName"`
 	Damage       int    `json:"damage"`
 	WasHit       bool   `json:"wasHit"`
 	WasCritical  bool   `json:"wasCritical"`
 	RemainingHP  int    `json:"remainingHp"`
+	Effect       string `json:"effect,omitempty"`   // Status effect the hit inflicted
+	Stunned      bool   `json:"stunned,omitempty"`  // Attacker was stunned and lost the attack
+	Absorbed     int    `json:"absorbed,omitempty"` // Damage the target's shield soaked up
 }
 
 // EnhancedCombatResult provides detailed combat information for the frontend
 type EnhancedCombatResult struct {
-	PlayerAttack  *AttackResult `json:"playerAttack,omitempty"`
-	EnemyAttack   *AttackResult `json:"enemyAttack,omitempty"`
-	EnemyDefeated bool          `json:"enemyDefeated"`
-	PlayerDied    bool          `json:"playerDied"`
+	PlayerAttacks []*AttackResult `json:"playerAttacks,omitempty"` // One per monster the player's action hit
+	EnemyAttacks  []*AttackResult `json:"enemyAttacks,omitempty"`  // One per monster that acted, in order
+	Cast          *CastResult     `json:"cast,omitempty"`          // Ability cast instead of attacking
+	EnemyDefeated bool            `json:"enemyDefeated"`           // At least one monster fell this round
+	Defeated      []string        `json:"defeated,omitempty"`      // IDs of the monsters that fell this round
+	PlayerDied    bool            `json:"playerDied"`
+	FleeAttempted bool            `json:"fleeAttempted,omitempty"`
+	Fled          bool            `json:"fled,omitempty"`     // Player escaped to the previous room
+	FleeRoll      int             `json:"fleeRoll,omitempty"` // d20 + dexterity modifier
+	FleeDC        int             `json:"fleeDc,omitempty"`   // Roll needed to escape
 }
 
 // InventoryDelta tracks changes to inventory this turn
@@ -137,16 +219,24 @@ type InventoryDelta struct {
 	Added   []string `json:"added,omitempty"`
 	Removed []string `json:"removed,omitempty"`
 	Used    []string `json:"used,omitempty"`
+	Dropped []string `json:"dropped,omitempty"` // Items that fell to the floor this turn (monster loot)
 }
 
 // GameContext provides contextual information about game progression
 type GameContext struct {
-	Phase             string  `json:"phase"`             // early_game, mid_game, late_game, exit
+	Phase             string  `json:"phase"` // early_game, mid_game, late_game, exit
+	Depth             int     `json:"depth"` // Current dungeon level, starting at 1
 	TurnsInRoom       int     `json:"turnsInRoom"`
 	ConsecutiveCombat int     `json:"consecutiveCombat"`
 	ExplorationPct    float64 `json:"explorationPct"`
 }
 
+// ActionRecord is one accepted tool call in a game's action log
+type ActionRecord struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
 // UIPanel represents a suggested UI component
 type UIPanel struct {
 	Type     string                 `json:"type"`
@@ -158,40 +248,83 @@ type UIPanel struct {
 
 // CharacterView is a frontend-friendly view of character state
 type CharacterView struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	HP        int    `json:"hp"`
-	MaxHP     int    `json:"maxHp"`
-	Strength  int    `json:"strength"`
-	Dexterity int    `json:"dexterity"`
-	IsAlive   bool   `json:"isAlive"`
-	Status    string `json:"status"` // "Healthy", "Wounded", "Critical", "Dead"
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Class      string         `json:"class"`
+	HP         int            `json:"hp"`
+	MaxHP      int            `json:"maxHp"`
+	Strength   int            `json:"strength"`
+	Dexterity  int            `json:"dexterity"`
+	IsAlive    bool           `json:"isAlive"`
+	Status     string         `json:"status"` // "Healthy", "Wounded", "Critical", "Dead"
+	Gold       int            `json:"gold"`
+	Level      int            `json:"level"`
+	XP         int            `json:"xp"`
+	XPToLevel  int            `json:"xpToLevel"`  // Total XP needed for the next level
+	StatPoints int            `json:"statPoints"` // Stat increases waiting for level_up
+	Capacity   int            `json:"capacity"`   // Items the character can carry
+	FreeSlots  int            `json:"freeSlots"`  // Items the character can still pick up
+	Mana       int            `json:"mana"`
+	MaxMana    int            `json:"maxMana"`
+	Shield     int            `json:"shield"` // Damage a ward will absorb
+	Abilities  []*AbilityView `json:"abilities"`
+	Effects    []*EffectView  `json:"effects"`
+}
+
+// AbilityView is a frontend-friendly view of an ability the character knows
+type AbilityView struct {
+	ID          string `json:"id"` // Name to pass to cast
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Kind        string `json:"kind"` // damage, heal, shield, reveal
+	ManaCost    int    `json:"manaCost"`
+	CanCast     bool   `json:"canCast"` // Enough mana, and allowed in the current situation
+}
+
+// EffectView is a frontend-friendly view of an active status effect
+type EffectView struct {
+	Type        string `json:"type"` // poison, bleed, stun, regeneration
+	Potency     int    `json:"potency"`
+	Duration    int    `json:"duration"` // Turns left
+	Description string `json:"description"`
 }
 
 // RoomView is a frontend-friendly view of a room
 type RoomView struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Description  string   `json:"description"`
-	IsEntrance   bool     `json:"isEntrance"`
-	IsExit       bool     `json:"isExit"`
-	X            int      `json:"x"`
-	Y            int      `json:"y"`
-	Exits        []string `json:"exits"` // Available exit directions
-	Atmosphere   string   `json:"atmosphere"`   // safe, tense, dangerous, mysterious, ominous
-	IsFirstVisit bool     `json:"isFirstVisit"`
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	IsEntrance   bool        `json:"isEntrance"`
+	IsExit       bool        `json:"isExit"`
+	X            int         `json:"x"`
+	Y            int         `json:"y"`
+	Exits        []string    `json:"exits"`      // Available exit directions
+	Atmosphere   string      `json:"atmosphere"` // safe, tense, dangerous, mysterious, ominous
+	IsFirstVisit bool        `json:"isFirstVisit"`
+	Traps        []*TrapView `json:"traps,omitempty"`       // Discovered, still-armed traps
+	LockedExits  []string    `json:"lockedExits,omitempty"` // Exits behind locked doors
 }
 
-// MonsterView is a frontend-friendly view of a monster
-type MonsterView struct {
+// TrapView is a frontend-friendly view of a discovered trap
+type TrapView struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	HP          int    `json:"hp"`
-	MaxHP       int    `json:"maxHp"`
 	Damage      int    `json:"damage"`
-	Threat      string `json:"threat"`      // trivial, normal, dangerous, deadly
-	IsDefeated  bool   `json:"isDefeated"`
+	Difficulty  int    `json:"difficulty"`
+}
+
+// MonsterView is a frontend-friendly view of a monster
+type MonsterView struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	HP          int           `json:"hp"`
+	MaxHP       int           `json:"maxHp"`
+	Damage      int           `json:"damage"`
+	Threat      string        `json:"threat"` // trivial, normal, dangerous, deadly
+	IsDefeated  bool          `json:"isDefeated"`
+	Effects     []*EffectView `json:"effects"`
 }
 
 // ItemView is a frontend-friendly view of an item
@@ -204,8 +337,22 @@ type ItemView struct {
 	Armor       int    `json:"armor,omitempty"`
 	Healing     int    `json:"healing,omitempty"`
 	Rarity      string `json:"rarity"` // common, uncommon, rare, legendary
+	Value       int    `json:"value,omitempty"`
 	IsEquipped  bool   `json:"isEquipped"`
 	IsNew       bool   `json:"isNew,omitempty"`
+	Effect      string `json:"effect,omitempty"` // Status effect a consumable applies, described
+	Cures       string `json:"cures,omitempty"`  // Status effect a consumable removes
+	OnHit       string `json:"onHit,omitempty"`  // Status effect a weapon's hits may inflict, described
+	Price       int    `json:"price,omitempty"`  // Gold a merchant asks for it, or pays for it from the inventory
+}
+
+// MerchantView is a frontend-friendly view of the merchant in the current room
+type MerchantView struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Stock       []*ItemView `json:"stock"`
+	CanTrade    bool        `json:"canTrade"` // False while monsters are in the room
 }
 
 // EquipmentView shows currently equipped items
@@ -216,12 +363,13 @@ type EquipmentView struct {
 
 // MapCell represents a single cell in the map grid
 type MapCell struct {
-	X         int    `json:"x"`
-	Y         int    `json:"y"`
-	RoomID    string `json:"roomId,omitempty"`
-	Status    string `json:"status"` // "unknown", "visited", "current", "adjacent", "exit"
-	HasPlayer bool   `json:"hasPlayer"`
-	Exits     []string `json:"exits,omitempty"` // Available directions
+	X           int      `json:"x"`
+	Y           int      `json:"y"`
+	RoomID      string   `json:"roomId,omitempty"`
+	Status      string   `json:"status"` // "unknown", "visited", "current", "adjacent", "revealed", "exit"
+	HasPlayer   bool     `json:"hasPlayer"`
+	Exits       []string `json:"exits,omitempty"`       // Available directions
+	LockedExits []string `json:"lockedExits,omitempty"` // Exits behind locked doors
 }
 
 // GameStateSnapshot is the complete game state for the frontend
@@ -230,15 +378,21 @@ type GameStateSnapshot struct {
 	CurrentRoom    *RoomView             `json:"currentRoom,omitempty"`
 	Monsters       []*MonsterView        `json:"monsters,omitempty"`
 	RoomItems      []*ItemView           `json:"roomItems,omitempty"`
+	Merchant       *MerchantView         `json:"merchant,omitempty"` // Merchant in the current room, for a shop panel
 	Inventory      []*ItemView           `json:"inventory,omitempty"`
 	Equipment      *EquipmentView        `json:"equipment,omitempty"`
 	MapGrid        [][]MapCell           `json:"mapGrid,omitempty"`
 	GameOver       bool                  `json:"gameOver"`
 	Victory        bool                  `json:"victory"`
 	TurnNumber     int                   `json:"turnNumber"`
+	Seed           int64                 `json:"seed"`              // Dungeon seed, shareable to replay the same run
+	Depth          int                   `json:"depth"`             // Current dungeon level, starting at 1
+	FinalDepth     int                   `json:"finalDepth"`        // Level whose exit escapes the dungeon
+	Score          *Score                `json:"score,omitempty"`   // Final score, once the run is over
 	Message        string                `json:"message,omitempty"` // Event message for transient notifications
 	Event          *EventInfo            `json:"event,omitempty"`
 	CombatResult   *EnhancedCombatResult `json:"combatResult,omitempty"`
 	InventoryDelta *InventoryDelta       `json:"inventoryDelta,omitempty"`
+	Arrivals       []string              `json:"arrivals,omitempty"` // Monsters that wandered into the room this turn
 	Context        *GameContext          `json:"context,omitempty"`
 }
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WanderChance is the chance each turn that a monster the character is not
+// fighting moves to a neighbouring room
+const WanderChance = 0.10
+
+// MonsterMove is one monster wandering from a room to a neighbouring one
+type MonsterMove struct {
+	MonsterID  string
+	FromRoomID string
+	ToRoomID   string
+}
+
+// MoveMonster puts a monster in another room, keeping the room index up to date
+func (gs *GameState) MoveMonster(monsterID, roomID string) error {
+	monster, ok := gs.Monsters[monsterID]
+	if !ok {
+		return fmt.Errorf("monster not found")
+	}
+	if _, ok := gs.Rooms[roomID]; !ok {
+		return fmt.Errorf("room not found")
+	}
+
+	delete(gs.MonstersByRoom[monster.RoomID], monsterID)
+	if len(gs.MonstersByRoom[monster.RoomID]) == 0 {
+		delete(gs.MonstersByRoom, monster.RoomID)
+	}
+	monster.RoomID = roomID
+	if gs.MonstersByRoom[roomID] == nil {
+		gs.MonstersByRoom[roomID] = make(map[string]bool)
+	}
+	gs.MonstersByRoom[roomID][monsterID] = true
+	return nil
+}
+
+// WanderMonsters runs the world tick after a player turn: each living monster
+// outside the character's room may move through an unlocked door to a
+// neighbouring room. Monsters never wander into the entrance or the exit, and
+// monsters already with the character stay to fight. Monsters are visited in
+// ID order and all rolls use the game's dice, so the tick replays exactly.
+func (gs *GameState) WanderMonsters() []MonsterMove {
+	if gs.Character == nil || !gs.Character.IsAlive {
+		return nil
+	}
+
+	ids := make([]string, 0, len(gs.Monsters))
+	for id, m := range gs.Monsters {
+		if m.IsAlive && m.RoomID != gs.Character.CurrentRoomID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	moves := make([]MonsterMove, 0)
+	for _, id := range ids {
+		monster := gs.Monsters[id]
+		if gs.Dice.Float64() >= WanderChance {
+			continue
+		}
+		destinations := gs.wanderDestinations(monster.RoomID)
+		if len(destinations) == 0 {
+			continue
+		}
+		move := MonsterMove{
+			MonsterID:  id,
+			FromRoomID: monster.RoomID,
+			ToRoomID:   destinations[gs.Dice.Intn(len(destinations))],
+		}
+		if err := gs.MoveMonster(id, move.ToRoomID); err != nil {
+			continue
+		}
+		moves = append(moves, move)
+	}
+	return moves
+}
+
+// wanderDestinations returns the rooms a monster can wander to from a room,
+// in a stable order
+func (gs *GameState) wanderDestinations(roomID string) []string {
+	destinations := make([]string, 0)
+	for _, conn := range gs.Connections[roomID] {
+		if conn.IsLocked {
+			continue
+		}
+		room, ok := gs.Rooms[conn.ConnectedRoomID]
+		if !ok || room.IsEntrance || room.IsExit {
+			continue
+		}
+		destinations = append(destinations, room.ID)
+	}
+	sort.Strings(destinations)
+	return destinations
+}
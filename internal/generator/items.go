@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// Item rarity tiers, from most to least common
+const (
+	RarityCommon    = "common"
+	RarityUncommon  = "uncommon"
+	RarityRare      = "rare"
+	RarityLegendary = "legendary"
+)
+
+// Rarity roll weights. Better tiers get likelier with room difficulty, which
+// already grows with depth.
+const (
+	CommonWeight           = 100
+	UncommonBaseWeight     = 30
+	UncommonWeightPerDiff  = 5
+	RareWeightPerDiff      = 4 // Rare items need a difficulty of at least 1
+	LegendaryMinDiff       = 5
+	LegendaryWeightPerDiff = 2 // Per difficulty above LegendaryMinDiff - 1
+	LegendaryAffixPower    = 2 // Legendary items get their affix bonuses this many times over
+)
+
+// Affix is a prefix ("Flaming") or suffix ("of Warding") that can be rolled
+// onto a generated item, changing its stats and adding to its name and
+// description
+type Affix struct {
+	Name        string
+	Description string // Sentence added to the item's description
+	Suffix      bool   // Goes after the base name instead of before it
+	Types       []string
+	Damage      int
+	Armor       int
+	Healing     int
+	Effect      *game.StatusEffect // Status effect a consumable applies
+	OnHit       *game.OnHitEffect  // Status effect a weapon's hits may inflict
+}
+
+var affixes = []Affix{
+	// Prefixes
+	{Name: "Sharp", Description: "Its edge has been honed razor-fine.", Types: []string{"weapon"}, Damage: 1},
+	{Name: "Flaming", Description: "Flames lick along its length.", Types: []string{"weapon"}, Damage: 2},
+	{Name: "Venomous", Description: "Venom beads along its edge.", Types: []string{"weapon"},
+		OnHit: &game.OnHitEffect{Chance: 30, Effect: game.StatusEffect{Type: game.EffectPoison, Potency: 1, Duration: 3}}},
+	{Name: "Serrated", Description: "Its cruel teeth leave wounds that will not close.", Types: []string{"weapon"},
+		OnHit: &game.OnHitEffect{Chance: 30, Effect: game.StatusEffect{Type: game.EffectBleed, Potency: 1, Duration: 3}}},
+	{Name: "Sturdy", Description: "Extra rivets hold it together.", Types: []string{"armor"}, Armor: 1},
+	{Name: "Runed", Description: "Faint runes glow across its face.", Types: []string{"armor"}, Armor: 2},
+	{Name: "Potent", Description: "It smells strongly of herbs.", Types: []string{"consumable"}, Healing: 5},
+
+	// Suffixes
+	{Name: "of Might", Description: "It strikes with uncanny force.", Suffix: true, Types: []string{"weapon"}, Damage: 2},
+	{Name: "of Thunder", Description: "It rings like a bell on impact.", Suffix: true, Types: []string{"weapon"},
+		OnHit: &game.OnHitEffect{Chance: 15, Effect: game.StatusEffect{Type: game.EffectStun, Duration: 1}}},
+	{Name: "of Warding", Description: "A protective ward hums around it.", Suffix: true, Types: []string{"armor"}, Armor: 2},
+	{Name: "of the Bulwark", Description: "Blows glance off it as if from a castle wall.", Suffix: true, Types: []string{"armor"}, Armor: 3},
+	{Name: "of Vigor", Description: "Drinking it sets the heart pounding.", Suffix: true, Types: []string{"consumable"}, Healing: 5},
+	{Name: "of Mending", Description: "Its warmth lingers long after drinking.", Suffix: true, Types: []string{"consumable"},
+		Effect: &game.StatusEffect{Type: game.EffectRegeneration, Potency: 1, Duration: 5}},
+}
+
+// appliesTo returns true if an affix can be rolled onto an item. Consumable
+// affixes only go on healing potions, and an item has at most one status
+// effect and one on-hit effect.
+func (a Affix) appliesTo(item *game.Item) bool {
+	matched := false
+	for _, t := range a.Types {
+		if t == item.Type {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if item.Type == "consumable" && (item.Healing == 0 || item.Effect != nil) {
+		return false
+	}
+	if a.OnHit != nil && item.OnHit != nil {
+		return false
+	}
+	return true
+}
+
+// isAffixable returns true if an item can have affixes at all
+func isAffixable(item *game.Item) bool {
+	for _, a := range affixes {
+		if a.appliesTo(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// rollRarity picks a rarity tier for an item found at a difficulty
+func (dg *DungeonGenerator) rollRarity(difficulty int) string {
+	tiers := []struct {
+		rarity string
+		weight int
+	}{
+		{RarityCommon, CommonWeight},
+		{RarityUncommon, UncommonBaseWeight + difficulty*UncommonWeightPerDiff},
+		{RarityRare, difficulty * RareWeightPerDiff},
+		{RarityLegendary, max(difficulty-LegendaryMinDiff+1, 0) * LegendaryWeightPerDiff},
+	}
+
+	total := 0
+	for _, t := range tiers {
+		total += t.weight
+	}
+	roll := dg.random.Intn(total)
+	for _, t := range tiers {
+		if roll < t.weight {
+			return t.rarity
+		}
+		roll -= t.weight
+	}
+	return RarityCommon
+}
+
+// addAffix picks a random affix that can go on an item, as a prefix or a
+// suffix, and applies it. Returns nil if there is none.
+func (dg *DungeonGenerator) addAffix(item *game.Item, suffix bool, power int) *Affix {
+	eligible := make([]Affix, 0)
+	for _, a := range affixes {
+		if a.Suffix == suffix && a.appliesTo(item) {
+			eligible = append(eligible, a)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	affix := eligible[dg.random.Intn(len(eligible))]
+	applyAffix(item, affix, power)
+	return &affix
+}
+
+// applyAffix adds an affix's bonuses to an item, power times over
+func applyAffix(item *game.Item, a Affix, power int) {
+	item.Damage += a.Damage * power
+	item.Armor += a.Armor * power
+	item.Healing += a.Healing * power
+	if a.Effect != nil {
+		effect := *a.Effect
+		effect.Potency *= power
+		item.Effect = &effect
+	}
+	if a.OnHit != nil {
+		onHit := *a.OnHit
+		onHit.Chance *= power
+		item.OnHit = &onHit
+	}
+}
+
+// GenerateItem creates an item from a base template for a room of the given
+// difficulty. Treasure is worth more deeper in; weapons, armor and healing
+// potions roll a rarity, and uncommon and better items get affixes: one for
+// uncommon, a prefix and a suffix for rare, and doubled prefix and suffix
+// bonuses for legendary. The name and description are composed from the base
+// and its affixes. The caller sets the item's ID and location.
+func (dg *DungeonGenerator) GenerateItem(template ItemTemplate, difficulty int) *game.Item {
+	item := newItemFromTemplate(template)
+	scaleFactor := 1.0 + float64(difficulty)*DifficultyScaleFactor
+	if item.Type == "treasure" {
+		item.Value = int(float64(item.Value) * scaleFactor)
+		return item
+	}
+	if !isAffixable(item) {
+		return item
+	}
+
+	rarity := dg.rollRarity(difficulty)
+	power := 1
+	var prefix, suffix *Affix
+	switch rarity {
+	case RarityUncommon:
+		if dg.random.Intn(2) == 0 {
+			prefix = dg.addAffix(item, false, power)
+		} else {
+			suffix = dg.addAffix(item, true, power)
+		}
+	case RarityLegendary:
+		power = LegendaryAffixPower
+		fallthrough
+	case RarityRare:
+		prefix = dg.addAffix(item, false, power)
+		suffix = dg.addAffix(item, true, power)
+	}
+	if prefix == nil && suffix == nil {
+		return item
+	}
+
+	name := []string{template.Name}
+	description := []string{template.Description}
+	if prefix != nil {
+		name = append([]string{prefix.Name}, name...)
+		description = append(description, prefix.Description)
+	}
+	if suffix != nil {
+		name = append(name, suffix.Name)
+		description = append(description, suffix.Description)
+	}
+	if rarity == RarityLegendary {
+		description = append(description, "Legends are told of it.")
+	}
+
+	item.Name = strings.Join(name, " ")
+	item.Description = strings.Join(description, " ")
+	item.Rarity = rarity
+	return item
+}
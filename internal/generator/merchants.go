@@ -0,0 +1,68 @@
+package generator
+
+import "github.com/yourusername/dungeon-crawler/internal/game"
+
+// Merchant constants
+const (
+	MerchantChance    = 0.5 // Chance for a level to have a merchant
+	MerchantStockSize = 4   // Items a merchant starts with
+)
+
+// MerchantTemplate defines a kind of merchant
+type MerchantTemplate struct {
+	Name        string
+	Description string
+}
+
+var merchantTemplates = []MerchantTemplate{
+	{Name: "Wandering Peddler", Description: "A stooped old man with a pack almost as big as he is."},
+	{Name: "Hooded Merchant", Description: "A figure in a deep hood, wares laid out on a threadbare rug."},
+	{Name: "Goblin Trader", Description: "A goblin in a stolen waistcoat who is, for once, more interested in gold than blood."},
+}
+
+// PlaceMerchant may put a merchant in one room of a level, away from the
+// entrance and the exit. Its stock is drawn from the item templates (treasure
+// and keys aside) and generated for the room's difficulty, so deeper
+// merchants sell better gear. Returns nil if the level has no merchant.
+func (dg *DungeonGenerator) PlaceMerchant(rooms []*game.Room, depth int) *game.Merchant {
+	if dg.random.Float64() >= MerchantChance {
+		return nil
+	}
+
+	var entrance *game.Room
+	candidates := make([]*game.Room, 0)
+	for _, room := range rooms {
+		if room.IsEntrance {
+			entrance = room
+		} else if !room.IsExit {
+			candidates = append(candidates, room)
+		}
+	}
+	if entrance == nil || len(candidates) == 0 {
+		return nil
+	}
+	room := candidates[dg.random.Intn(len(candidates))]
+	difficulty := LevelDifficulty(room, entrance, depth)
+
+	wares := make([]ItemTemplate, 0)
+	for _, it := range itemTemplates {
+		if it.Type != "treasure" {
+			wares = append(wares, it)
+		}
+	}
+
+	template := merchantTemplates[dg.random.Intn(len(merchantTemplates))]
+	merchant := &game.Merchant{
+		ID:          dg.generateID(),
+		RoomID:      room.ID,
+		Name:        template.Name,
+		Description: template.Description,
+		Stock:       make([]*game.Item, 0, MerchantStockSize),
+	}
+	for i := 0; i < MerchantStockSize; i++ {
+		item := dg.GenerateItem(wares[dg.random.Intn(len(wares))], difficulty)
+		item.ID = dg.generateID()
+		merchant.Stock = append(merchant.Stock, item)
+	}
+	return merchant
+}
@@ -1,16 +1,20 @@
 package generator
 
 import (
-	"crypto/rand"
 	"fmt"
 	mrand "math/rand"
+	"sort"
+	"time"
 
 	"github.com/yourusername/dungeon-crawler/internal/game"
 )
 
 // Grid and generation constants
 const (
-	GridSize = 5 // Dungeon grid dimensions (5x5)
+	DefaultWidth  = 5  // Dungeon grid width unless a game chooses its own
+	DefaultHeight = 5  // Dungeon grid height unless a game chooses its own
+	MinGridSize   = 3  // Smallest width or height allowed
+	MaxGridSize   = 12 // Largest width or height allowed
 
 	// Door distribution probabilities
 	OneDoorExtraChance = 0.75 // Chance to add door to 1-door room
@@ -28,30 +32,113 @@ const (
 	ItemChancePerDiff = 0.05 // Additional item chance per difficulty
 	MaxItemChance     = 0.50 // Maximum item spawn chance
 
+	// Trap spawn constants
+	BaseTrapChance    = 0.10 // Base chance for a trap in an eligible room
+	TrapChancePerDiff = 0.05 // Additional trap chance per difficulty
+	MaxTrapChance     = 0.35 // Maximum trap spawn chance
+	TrapDCPerDiff     = 2    // Difficulty levels per +1 to a trap's DC
+
+	// Locked door constants
+	MaxLockedDoors   = 2   // Most doors locked per dungeon
+	LockedDoorChance = 0.6 // Chance to place each possible lock
+
+	// Depth constants
+	DifficultyPerDepth = 2 // Extra room difficulty per level below the first
+
 	// Description weighting
 	ScaryDescriptionDist    = 4   // Distance threshold for scary descriptions
 	ScaryDescriptionChance  = 0.5 // Chance to use scarier description
 )
 
+// SizePreset is a named dungeon size offered by new_game
+type SizePreset struct {
+	Width  int
+	Height int
+}
+
+// SizePresets are the difficulty presets: bigger dungeons put the exit
+// further away, so its rooms are more dangerous
+var SizePresets = map[string]SizePreset{
+	"easy":   {Width: 4, Height: 4},
+	"normal": {Width: DefaultWidth, Height: DefaultHeight},
+	"hard":   {Width: 7, Height: 7},
+}
+
 // DungeonGenerator handles procedural dungeon generation
 type DungeonGenerator struct {
-	seed   int64
-	random *mrand.Rand
+	seed     int64
+	random   *mrand.Rand
+	idPrefix string
+	width    int
+	height   int
 }
 
-// generateID creates a simple random ID
-func generateID() string {
-	b := make([]byte, 16)
-	rand.Read(b)
-	return fmt.Sprintf("%x", b)
+// generateID creates an ID from the seeded random source, so the same seed
+// and prefix always produce the same IDs
+func (dg *DungeonGenerator) generateID() string {
+	return fmt.Sprintf("%s%016x", dg.idPrefix, dg.random.Uint64())
 }
 
 // NewDungeonGenerator creates a new dungeon generator
 func NewDungeonGenerator(seed int64) *DungeonGenerator {
 	return &DungeonGenerator{
-		seed:   seed,
-		random: mrand.New(mrand.NewSource(seed)),
+		seed:     seed,
+		random:   mrand.New(mrand.NewSource(seed)),
+		idPrefix: game.NewIDPrefix(),
+		width:    DefaultWidth,
+		height:   DefaultHeight,
+	}
+}
+
+// NewLevelGenerator creates a generator for one level of a run. Each depth
+// draws from its own seed derived from the run's seed, so a level is the same
+// however the levels above it were played. Depth 1 uses the run's seed as is.
+func NewLevelGenerator(seed int64, depth int) *DungeonGenerator {
+	dg := NewDungeonGenerator(seed)
+	dg.random = mrand.New(mrand.NewSource(LevelSeed(seed, depth)))
+	return dg
+}
+
+// levelSeedStride spreads level seeds apart so neighbouring run seeds don't
+// share levels
+const levelSeedStride = 0x2545F4914F6CDD1D
+
+// LevelSeed returns the seed that generates a given depth of a run
+func LevelSeed(seed int64, depth int) int64 {
+	if depth <= 1 {
+		return seed
+	}
+	return seed + int64(depth-1)*levelSeedStride
+}
+
+// WithIDPrefix sets the prefix for generated IDs (by default a random one).
+// Reusing a game's prefix with its seed reproduces its IDs exactly.
+func (dg *DungeonGenerator) WithIDPrefix(prefix string) *DungeonGenerator {
+	dg.idPrefix = prefix
+	return dg
+}
+
+// WithSize sets the grid dimensions, clamped to MinGridSize..MaxGridSize
+func (dg *DungeonGenerator) WithSize(width, height int) *DungeonGenerator {
+	dg.width = ClampGridSize(width)
+	dg.height = ClampGridSize(height)
+	return dg
+}
+
+// ClampGridSize limits a grid dimension to the supported range
+func ClampGridSize(n int) int {
+	if n < MinGridSize {
+		return MinGridSize
 	}
+	if n > MaxGridSize {
+		return MaxGridSize
+	}
+	return n
+}
+
+// IDPrefix returns the prefix used for generated IDs
+func (dg *DungeonGenerator) IDPrefix() string {
+	return dg.idPrefix
 }
 
 // coord represents a position in the grid
@@ -68,36 +155,45 @@ type edge struct {
 // GenerateDungeon creates a new procedural dungeon
 func (dg *DungeonGenerator) GenerateDungeon(depth int) (*game.Dungeon, []*game.Room, []*game.RoomConnection, error) {
 	dungeon := &game.Dungeon{
-		ID:    generateID(),
-		Seed:  dg.seed,
-		Depth: depth,
+		ID:        dg.generateID(),
+		Seed:      dg.seed,
+		Depth:     depth,
+		Width:     dg.width,
+		Height:    dg.height,
+		CreatedAt: time.Now(),
 	}
 
-	// Generate 5x5 grid
 	rooms, roomGrid := dg.generateGrid(dungeon.ID)
 
-	// Generate spanning tree for connectivity, then add extra doors
-	connections := dg.generateConnections(roomGrid)
+	// The entrance goes on the edge of the grid; the spanning tree grows from
+	// it, and the exit goes in a room as many doors away as possible
+	entrance := dg.pickEntrance()
+	roomGrid[entrance].IsEntrance = true
+	connections := dg.generateConnections(roomGrid, entrance)
+	exit := dg.pickExit(roomGrid, entrance, connections)
+	roomGrid[exit].IsExit = true
+
+	for _, c := range sortedCoords(roomGrid) {
+		room := roomGrid[c]
+		room.Description = dg.generateRoomDescription(room, manhattan(c, entrance))
+	}
 
 	return dungeon, rooms, connections, nil
 }
 
-// generateGrid creates a 5x5 grid of rooms
+// generateGrid creates a width x height grid of rooms
 func (dg *DungeonGenerator) generateGrid(dungeonID string) ([]*game.Room, map[coord]*game.Room) {
-	rooms := make([]*game.Room, 0, GridSize*GridSize)
+	rooms := make([]*game.Room, 0, dg.width*dg.height)
 	roomGrid := make(map[coord]*game.Room)
 
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
+	for y := 0; y < dg.height; y++ {
+		for x := 0; x < dg.width; x++ {
 			room := &game.Room{
-				ID:          generateID(),
-				DungeonID:   dungeonID,
-				Name:        dg.generateRoomName(),
-				Description: dg.generateRoomDescription(x, y),
-				IsEntrance:  x == 0 && y == 0,
-				IsExit:      x == GridSize-1 && y == GridSize-1,
-				X:           x,
-				Y:           y,
+				ID:        dg.generateID(),
+				DungeonID: dungeonID,
+				Name:      dg.generateRoomName(),
+				X:         x,
+				Y:         y,
 			}
 			rooms = append(rooms, room)
 			roomGrid[coord{x, y}] = room
@@ -107,8 +203,78 @@ func (dg *DungeonGenerator) generateGrid(dungeonID string) ([]*game.Room, map[co
 	return rooms, roomGrid
 }
 
+// pickEntrance chooses a random cell on the edge of the grid
+func (dg *DungeonGenerator) pickEntrance() coord {
+	edgeCells := make([]coord, 0)
+	for y := 0; y < dg.height; y++ {
+		for x := 0; x < dg.width; x++ {
+			if x == 0 || y == 0 || x == dg.width-1 || y == dg.height-1 {
+				edgeCells = append(edgeCells, coord{x, y})
+			}
+		}
+	}
+	return edgeCells[dg.random.Intn(len(edgeCells))]
+}
+
+// pickExit chooses the room furthest from the entrance by the number of doors
+// walked through, picking at random between equally distant rooms
+func (dg *DungeonGenerator) pickExit(roomGrid map[coord]*game.Room, entrance coord, connections []*game.RoomConnection) coord {
+	coordByID := make(map[string]coord, len(roomGrid))
+	for c, room := range roomGrid {
+		coordByID[room.ID] = c
+	}
+	neighbors := make(map[coord][]coord)
+	for _, conn := range connections {
+		from, to := coordByID[conn.RoomID], coordByID[conn.ConnectedRoomID]
+		neighbors[from] = append(neighbors[from], to)
+	}
+
+	// Breadth-first search from the entrance
+	dist := map[coord]int{entrance: 0}
+	queue := []coord{entrance}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, n := range neighbors[c] {
+			if _, seen := dist[n]; !seen {
+				dist[n] = dist[c] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	farthest := make([]coord, 0)
+	maxDist := -1
+	for _, c := range sortedCoords(roomGrid) {
+		d, ok := dist[c]
+		if !ok || c == entrance {
+			continue
+		}
+		if d > maxDist {
+			maxDist = d
+			farthest = farthest[:0]
+		}
+		if d == maxDist {
+			farthest = append(farthest, c)
+		}
+	}
+	return farthest[dg.random.Intn(len(farthest))]
+}
+
+// manhattan returns the grid distance between two coords
+func manhattan(a, b coord) int {
+	dx, dy := a.x-b.x, a.y-b.y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
+
 // generateConnections creates room connections using spanning tree + extra doors
-func (dg *DungeonGenerator) generateConnections(roomGrid map[coord]*game.Room) []*game.RoomConnection {
+func (dg *DungeonGenerator) generateConnections(roomGrid map[coord]*game.Room, start coord) []*game.RoomConnection {
 	// Track which edges exist (bidirectional)
 	edges := make(map[coord]map[string]bool) // coord -> direction -> exists
 	for c := range roomGrid {
@@ -119,8 +285,7 @@ func (dg *DungeonGenerator) generateConnections(roomGrid map[coord]*game.Room) [
 	visited := make(map[coord]bool)
 	frontier := make([]edge, 0)
 
-	// Start from entrance (0,0)
-	start := coord{0, 0}
+	// Start from the entrance
 	visited[start] = true
 	dg.addFrontierEdges(&frontier, start, visited)
 
@@ -183,15 +348,19 @@ func (dg *DungeonGenerator) generateConnections(roomGrid map[coord]*game.Room) [
 	}
 
 	// Step 3: Convert edges map to RoomConnection slice
+	// (walk coords and directions in a fixed order so IDs are reproducible)
 	connections := make([]*game.RoomConnection, 0)
-	for c, dirs := range edges {
+	for _, c := range sortedCoords(roomGrid) {
 		room := roomGrid[c]
-		for dir := range dirs {
+		for _, dir := range allDirections {
+			if !edges[c][dir] {
+				continue
+			}
 			neighbor := getNeighbor(c, dir)
 			neighborRoom := roomGrid[neighbor]
 			if neighborRoom != nil {
 				connections = append(connections, &game.RoomConnection{
-					ID:              generateID(),
+					ID:              dg.generateID(),
 					RoomID:          room.ID,
 					Direction:       dir,
 					ConnectedRoomID: neighborRoom.ID,
@@ -205,10 +374,9 @@ func (dg *DungeonGenerator) generateConnections(roomGrid map[coord]*game.Room) [
 
 // addFrontierEdges adds all edges from a coord to unvisited neighbors
 func (dg *DungeonGenerator) addFrontierEdges(frontier *[]edge, c coord, visited map[coord]bool) {
-	directions := []string{"north", "south", "east", "west"}
-	for _, dir := range directions {
+	for _, dir := range allDirections {
 		neighbor := getNeighbor(c, dir)
-		if neighbor.x >= 0 && neighbor.x < GridSize && neighbor.y >= 0 && neighbor.y < GridSize {
+		if neighbor.x >= 0 && neighbor.x < dg.width && neighbor.y >= 0 && neighbor.y < dg.height {
 			if !visited[neighbor] {
 				*frontier = append(*frontier, edge{from: c, to: neighbor, direction: dir})
 			}
@@ -221,7 +389,7 @@ func (dg *DungeonGenerator) getPossibleEdges(roomGrid map[coord]*game.Room, edge
 	possible := make([]edge, 0)
 	directions := []string{"north", "east"} // Only check two directions to avoid duplicates
 
-	for c := range roomGrid {
+	for _, c := range sortedCoords(roomGrid) {
 		for _, dir := range directions {
 			neighbor := getNeighbor(c, dir)
 			if roomGrid[neighbor] != nil && !edges[c][dir] {
@@ -240,6 +408,26 @@ func (dg *DungeonGenerator) shuffleEdges(edges []edge) {
 	}
 }
 
+// allDirections lists the directions in the order they are generated
+var allDirections = []string{"north", "south", "east", "west"}
+
+// sortedCoords returns the grid's coords row by row. Map iteration order is
+// random, so anything that consumes the seeded random source must walk the
+// grid through this to stay reproducible.
+func sortedCoords(roomGrid map[coord]*game.Room) []coord {
+	coords := make([]coord, 0, len(roomGrid))
+	for c := range roomGrid {
+		coords = append(coords, c)
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].y != coords[j].y {
+			return coords[i].y < coords[j].y
+		}
+		return coords[i].x < coords[j].x
+	})
+	return coords
+}
+
 // getNeighbor returns the coord in the given direction
 func getNeighbor(c coord, dir string) coord {
 	switch dir {
@@ -281,14 +469,13 @@ func (dg *DungeonGenerator) generateRoomName() string {
 	return fmt.Sprintf("%s %s", adj, noun)
 }
 
-// generateRoomDescription creates a description based on position
-func (dg *DungeonGenerator) generateRoomDescription(x, y int) string {
-	distance := x + y // Manhattan distance from entrance
-
-	if x == 0 && y == 0 {
+// generateRoomDescription creates a description based on the room's role and
+// its distance from the entrance
+func (dg *DungeonGenerator) generateRoomDescription(room *game.Room, distance int) string {
+	if room.IsEntrance {
 		return "The entrance to the dungeon. Faint light filters in from behind you."
 	}
-	if x == GridSize-1 && y == GridSize-1 {
+	if room.IsExit {
 		return "A grand chamber with an ornate door leading to freedom!"
 	}
 
@@ -319,6 +506,15 @@ type MonsterTemplate struct {
 	BaseHP      int
 	BaseDamage  int
 	MinDiff     int // minimum difficulty to spawn
+	Loot        []LootDrop
+	OnHit       *game.OnHitEffect // status effect its hits may inflict
+}
+
+// LootDrop is a weighted entry in a monster template's loot table. An empty
+// item name is the weight of dropping nothing.
+type LootDrop struct {
+	Item   string // ItemTemplate name
+	Weight int
 }
 
 // ItemTemplate defines an item type
@@ -329,24 +525,109 @@ type ItemTemplate struct {
 	Damage      int
 	Armor       int
 	Healing     int
-	Rarity      string // common, uncommon, rare, legendary
+	Rarity      string             // Base rarity; generated items may roll better
+	Value       int                // Treasure only: worth in gold, growing with difficulty
+	Effect      *game.StatusEffect // Status effect a consumable applies
+	Cures       string             // Status effect a consumable removes
+}
+
+// TrapTemplate defines a trap type
+type TrapTemplate struct {
+	Name        string
+	Description string
+	BaseDamage  int
+	BaseDC      int // DC to avoid, detect, or disarm
+	MinDiff     int
+}
+
+var trapTemplates = []TrapTemplate{
+	{Name: "Dart Trap", Description: "A loose flagstone that fires a volley of darts from the walls.", BaseDamage: 3, BaseDC: 10, MinDiff: 1},
+	{Name: "Spiked Pit", Description: "A concealed pit lined with sharpened stakes.", BaseDamage: 5, BaseDC: 12, MinDiff: 2},
+	{Name: "Scything Blade", Description: "A rusted blade that swings out of the wall at knee height.", BaseDamage: 6, BaseDC: 13, MinDiff: 3},
+	{Name: "Gas Vent", Description: "Hidden vents that hiss with choking green gas.", BaseDamage: 7, BaseDC: 14, MinDiff: 4},
 }
 
 var monsterTemplates = []MonsterTemplate{
-	{Name: "Rat", Description: "A large, mangy rat with beady red eyes.", BaseHP: 5, BaseDamage: 2, MinDiff: 0},
-	{Name: "Goblin", Description: "A small, green-skinned creature with a wicked grin.", BaseHP: 10, BaseDamage: 4, MinDiff: 1},
-	{Name: "Skeleton", Description: "The animated bones of a long-dead warrior.", BaseHP: 15, BaseDamage: 5, MinDiff: 2},
-	{Name: "Orc", Description: "A hulking brute with tusks and a massive club.", BaseHP: 25, BaseDamage: 8, MinDiff: 3},
-	{Name: "Wraith", Description: "A shadowy figure that chills you to the bone.", BaseHP: 20, BaseDamage: 7, MinDiff: 4},
+	{Name: "Rat", Description: "A large, mangy rat with beady red eyes.", BaseHP: 5, BaseDamage: 2, MinDiff: 0,
+		Loot:  []LootDrop{{Weight: 7}, {Item: "Health Potion", Weight: 2}, {Item: "Antidote", Weight: 1}, {Item: "Gold Coins", Weight: 1}},
+		OnHit: &game.OnHitEffect{Chance: 30, Effect: game.StatusEffect{Type: game.EffectPoison, Potency: 1, Duration: 3}}},
+	{Name: "Goblin", Description: "A small, green-skinned creature with a wicked grin.", BaseHP: 10, BaseDamage: 4, MinDiff: 1,
+		Loot:  []LootDrop{{Weight: 4}, {Item: "Health Potion", Weight: 2}, {Item: "Bandage", Weight: 1}, {Item: "Rusty Sword", Weight: 2}, {Item: "Gold Coins", Weight: 2}},
+		OnHit: &game.OnHitEffect{Chance: 25, Effect: game.StatusEffect{Type: game.EffectBleed, Potency: 1, Duration: 3}}},
+	{Name: "Skeleton", Description: "The animated bones of a long-dead warrior.", BaseHP: 15, BaseDamage: 5, MinDiff: 2,
+		Loot: []LootDrop{{Weight: 4}, {Item: "Rusty Sword", Weight: 2}, {Item: "Wooden Shield", Weight: 2}, {Item: "Short Sword", Weight: 1}, {Item: "Silver Chalice", Weight: 1}}},
+	{Name: "Orc", Description: "A hulking brute with tusks and a massive club.", BaseHP: 25, BaseDamage: 8, MinDiff: 3,
+		Loot:  []LootDrop{{Weight: 3}, {Item: "Greater Health Potion", Weight: 2}, {Item: "Flash Powder", Weight: 1}, {Item: "Short Sword", Weight: 2}, {Item: "Iron Shield", Weight: 2}, {Item: "Gold Coins", Weight: 1}},
+		OnHit: &game.OnHitEffect{Chance: 20, Effect: game.StatusEffect{Type: game.EffectStun, Duration: 1}}},
+	{Name: "Wraith", Description: "A shadowy figure that chills you to the bone.", BaseHP: 20, BaseDamage: 7, MinDiff: 4,
+		Loot:  []LootDrop{{Weight: 3}, {Item: "Greater Health Potion", Weight: 3}, {Item: "Regeneration Draught", Weight: 1}, {Item: "Iron Shield", Weight: 2}, {Item: "Jeweled Idol", Weight: 2}},
+		OnHit: &game.OnHitEffect{Chance: 25, Effect: game.StatusEffect{Type: game.EffectPoison, Potency: 2, Duration: 3}}},
 }
 
 var itemTemplates = []ItemTemplate{
 	{Name: "Health Potion", Description: "A red vial that restores health.", Type: "consumable", Healing: 10, Rarity: "common"},
 	{Name: "Greater Health Potion", Description: "A large red vial that restores significant health.", Type: "consumable", Healing: 20, Rarity: "uncommon"},
+	{Name: "Antidote", Description: "A bitter green tonic that purges poison.", Type: "consumable", Cures: game.EffectPoison, Rarity: "common"},
+	{Name: "Bandage", Description: "A roll of clean linen to bind a wound.", Type: "consumable", Cures: game.EffectBleed, Rarity: "common"},
+	{Name: "Regeneration Draught", Description: "A warm amber draught that knits wounds over time.", Type: "consumable",
+		Effect: &game.StatusEffect{Type: game.EffectRegeneration, Potency: 2, Duration: 5}, Rarity: "uncommon"},
+	{Name: "Poison Vial", Description: "A stoppered vial of venom, made to be thrown.", Type: "consumable",
+		Effect: &game.StatusEffect{Type: game.EffectPoison, Potency: 2, Duration: 4}, Rarity: "uncommon"},
+	{Name: "Flash Powder", Description: "A pouch of powder that bursts into blinding light.", Type: "consumable",
+		Effect: &game.StatusEffect{Type: game.EffectStun, Duration: 1}, Rarity: "uncommon"},
 	{Name: "Rusty Sword", Description: "An old sword, still sharp enough to cut.", Type: "weapon", Damage: 3, Rarity: "common"},
 	{Name: "Short Sword", Description: "A well-balanced blade.", Type: "weapon", Damage: 5, Rarity: "uncommon"},
 	{Name: "Wooden Shield", Description: "A simple wooden shield that provides basic protection.", Type: "armor", Armor: 2, Rarity: "common"},
 	{Name: "Iron Shield", Description: "A sturdy iron shield.", Type: "armor", Armor: 4, Rarity: "uncommon"},
+	{Name: "Gold Coins", Description: "A scattering of tarnished gold coins.", Type: "treasure", Rarity: "common", Value: 10},
+	{Name: "Silver Chalice", Description: "An ornate chalice, dented but valuable.", Type: "treasure", Rarity: "uncommon", Value: 25},
+	{Name: "Jeweled Idol", Description: "A small idol set with glittering gems.", Type: "treasure", Rarity: "rare", Value: 60},
+}
+
+// findItemTemplate looks up an item template by name
+func findItemTemplate(name string) (ItemTemplate, bool) {
+	for _, it := range itemTemplates {
+		if it.Name == name {
+			return it, true
+		}
+	}
+	return ItemTemplate{}, false
+}
+
+// newItemFromTemplate creates an item from a template. The caller sets its ID
+// and location.
+func newItemFromTemplate(template ItemTemplate) *game.Item {
+	return &game.Item{
+		Name:        template.Name,
+		Description: template.Description,
+		Type:        template.Type,
+		Damage:      template.Damage,
+		Armor:       template.Armor,
+		Healing:     template.Healing,
+		Rarity:      template.Rarity,
+		Value:       template.Value,
+		Effect:      template.Effect,
+		Cures:       template.Cures,
+	}
+}
+
+// buildLootTable turns a template's loot drops into a monster loot table.
+// Each drop is generated for the monster's difficulty, so deeper monsters
+// carry richer treasure and better gear.
+func (dg *DungeonGenerator) buildLootTable(drops []LootDrop, difficulty int) []game.LootEntry {
+	table := make([]game.LootEntry, 0, len(drops))
+	for _, drop := range drops {
+		entry := game.LootEntry{Weight: drop.Weight}
+		if drop.Item != "" {
+			template, ok := findItemTemplate(drop.Item)
+			if !ok {
+				continue
+			}
+			entry.Item = dg.GenerateItem(template, difficulty)
+		}
+		table = append(table, entry)
+	}
+	return table
 }
 
 // PopulateRoom adds monsters, items, and traps to a room
@@ -358,15 +639,10 @@ func (dg *DungeonGenerator) PopulateRoom(room *game.Room, difficulty int) ([]*ga
 	// Entrance room is safe - no monsters
 	if room.IsEntrance {
 		// Give the player a starting health potion
-		startPotion := &game.Item{
-			ID:          generateID(),
-			Name:        "Health Potion",
-			Description: "A red vial that restores health.",
-			Type:        "consumable",
-			Healing:     10,
-			Rarity:      "common",
-			RoomID:      &room.ID,
-		}
+		potion, _ := findItemTemplate("Health Potion")
+		startPotion := newItemFromTemplate(potion)
+		startPotion.ID = dg.generateID()
+		startPotion.RoomID = &room.ID
 		items = append(items, startPotion)
 		return monsters, items, traps
 	}
@@ -400,7 +676,7 @@ func (dg *DungeonGenerator) PopulateRoom(room *game.Room, difficulty int) ([]*ga
 			// Scale HP and damage with difficulty
 			scaleFactor := 1.0 + float64(difficulty)*DifficultyScaleFactor
 			monster := &game.Monster{
-				ID:          generateID(),
+				ID:          dg.generateID(),
 				Name:        template.Name,
 				Description: template.Description,
 				HP:          int(float64(template.BaseHP) * scaleFactor),
@@ -408,6 +684,8 @@ func (dg *DungeonGenerator) PopulateRoom(room *game.Room, difficulty int) ([]*ga
 				Damage:      int(float64(template.BaseDamage) * scaleFactor),
 				RoomID:      room.ID,
 				IsAlive:     true,
+				LootTable:   dg.buildLootTable(template.Loot, difficulty),
+				OnHit:       template.OnHit,
 			}
 			monsters = append(monsters, monster)
 		}
@@ -420,26 +698,162 @@ func (dg *DungeonGenerator) PopulateRoom(room *game.Room, difficulty int) ([]*ga
 	}
 
 	if dg.random.Float64() < itemChance {
-		// Pick a random item
+		// Pick a random base item and roll its rarity and affixes
 		template := itemTemplates[dg.random.Intn(len(itemTemplates))]
-		item := &game.Item{
-			ID:          generateID(),
+		item := dg.GenerateItem(template, difficulty)
+		item.ID = dg.generateID()
+		item.RoomID = &room.ID
+		items = append(items, item)
+	}
+
+	// Chance to spawn a trap, from the traps eligible at this difficulty
+	eligibleTraps := make([]TrapTemplate, 0)
+	for _, tt := range trapTemplates {
+		if tt.MinDiff <= difficulty {
+			eligibleTraps = append(eligibleTraps, tt)
+		}
+	}
+
+	trapChance := BaseTrapChance + float64(difficulty)*TrapChancePerDiff
+	if trapChance > MaxTrapChance {
+		trapChance = MaxTrapChance
+	}
+
+	if len(eligibleTraps) > 0 && dg.random.Float64() < trapChance {
+		template := eligibleTraps[dg.random.Intn(len(eligibleTraps))]
+		scaleFactor := 1.0 + float64(difficulty)*DifficultyScaleFactor
+		trap := &game.Trap{
+			ID:          dg.generateID(),
+			RoomID:      room.ID,
 			Name:        template.Name,
 			Description: template.Description,
-			Type:        template.Type,
-			Damage:      template.Damage,
-			Armor:       template.Armor,
-			Healing:     template.Healing,
-			Rarity:      template.Rarity,
-			RoomID:      &room.ID,
+			Damage:      int(float64(template.BaseDamage) * scaleFactor),
+			Difficulty:  template.BaseDC + difficulty/TrapDCPerDiff,
 		}
-		items = append(items, item)
+		traps = append(traps, trap)
 	}
 
 	return monsters, items, traps
 }
 
+// keyNames are the kinds of key that open locked doors
+var keyNames = []string{"Iron Key", "Brass Key", "Bone Key", "Silver Key"}
+
+// door is a connection between two rooms, in both directions
+type door struct {
+	there, back *game.RoomConnection
+	locked      bool
+	keyRoomID   string // room holding the key, once placed
+}
+
+// PlaceLocks locks some doors and puts a key for each in a room. A door is
+// only locked if its key can go in a room that is still reachable from the
+// entrance with the door locked (using the keys placed so far), so the exit
+// can always be reached. Returns the keys, lying in their rooms.
+func (dg *DungeonGenerator) PlaceLocks(rooms []*game.Room, connections []*game.RoomConnection) []*game.Item {
+	var entrance *game.Room
+	for _, room := range rooms {
+		if room.IsEntrance {
+			entrance = room
+		}
+	}
+	if entrance == nil {
+		return nil
+	}
+
+	// Pair up the two directions of each door
+	doors := make([]*door, 0)
+	byEnds := make(map[string]*door)
+	for _, conn := range connections {
+		if d, ok := byEnds[conn.ConnectedRoomID+"|"+conn.RoomID]; ok {
+			d.back = conn
+			continue
+		}
+		d := &door{there: conn}
+		byEnds[conn.RoomID+"|"+conn.ConnectedRoomID] = d
+		doors = append(doors, d)
+	}
+
+	keys := make([]*game.Item, 0)
+	for i := 0; i < MaxLockedDoors; i++ {
+		if dg.random.Float64() >= LockedDoorChance {
+			continue
+		}
+
+		// Doors out of the entrance stay open so there is always somewhere to go
+		candidates := make([]*door, 0)
+		for _, d := range doors {
+			if !d.locked && d.back != nil && d.there.RoomID != entrance.ID && d.there.ConnectedRoomID != entrance.ID {
+				candidates = append(candidates, d)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		d := candidates[dg.random.Intn(len(candidates))]
+
+		// The key goes in a room reachable with this door locked, other than
+		// the entrance (too easy) and the exit (the game ends there)
+		d.locked = true
+		reachable := reachableRooms(entrance.ID, doors)
+		keyRooms := make([]*game.Room, 0)
+		for _, room := range rooms {
+			if reachable[room.ID] && !room.IsEntrance && !room.IsExit {
+				keyRooms = append(keyRooms, room)
+			}
+		}
+		if len(keyRooms) == 0 {
+			d.locked = false
+			continue
+		}
+		keyRoom := keyRooms[dg.random.Intn(len(keyRooms))]
+		d.keyRoomID = keyRoom.ID
+
+		key := &game.Item{
+			ID:          dg.generateID(),
+			Name:        keyNames[len(keys)%len(keyNames)],
+			Description: "A worn key. It opens a locked door somewhere in this dungeon.",
+			Type:        "key",
+			Rarity:      "common",
+			RoomID:      &keyRoom.ID,
+		}
+		for _, conn := range []*game.RoomConnection{d.there, d.back} {
+			conn.IsLocked = true
+			conn.KeyID = key.ID
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// reachableRooms finds the rooms the player can reach from a start room,
+// passing locked doors only once the room holding their key is reachable
+func reachableRooms(startID string, doors []*door) map[string]bool {
+	reachable := map[string]bool{startID: true}
+	for changed := true; changed; {
+		changed = false
+		for _, d := range doors {
+			if d.locked && (d.keyRoomID == "" || !reachable[d.keyRoomID]) {
+				continue
+			}
+			from, to := d.there.RoomID, d.there.ConnectedRoomID
+			if reachable[from] != reachable[to] {
+				reachable[from], reachable[to] = true, true
+				changed = true
+			}
+		}
+	}
+	return reachable
+}
+
 // GetRoomDifficulty calculates difficulty based on Manhattan distance from entrance
-func GetRoomDifficulty(room *game.Room) int {
-	return room.X + room.Y
+func GetRoomDifficulty(room, entrance *game.Room) int {
+	return manhattan(coord{room.X, room.Y}, coord{entrance.X, entrance.Y})
+}
+
+// LevelDifficulty is a room's difficulty on a given level: its distance from
+// the entrance plus a step for every level below the first
+func LevelDifficulty(room, entrance *game.Room, depth int) int {
+	return GetRoomDifficulty(room, entrance) + (depth-1)*DifficultyPerDepth
 }
@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// testDoor builds an unlocked door between two rooms, in both directions
+func testDoor(from, to string) *door {
+	return &door{
+		there: &game.RoomConnection{RoomID: from, ConnectedRoomID: to},
+		back:  &game.RoomConnection{RoomID: to, ConnectedRoomID: from},
+	}
+}
+
+func TestReachableRooms(t *testing.T) {
+	// a - b - c - d in a line, with the door between b and c locked
+	tests := []struct {
+		name      string
+		keyRoomID string
+		want      []string
+	}{
+		{"key not placed", "", []string{"a", "b"}},
+		{"key before the door", "b", []string{"a", "b", "c", "d"}},
+		{"key behind its own door", "d", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked := testDoor("b", "c")
+			locked.locked = true
+			locked.keyRoomID = tt.keyRoomID
+			doors := []*door{testDoor("a", "b"), locked, testDoor("c", "d")}
+
+			reachable := reachableRooms("a", doors)
+			if len(reachable) != len(tt.want) {
+				t.Errorf("reached %v, want %v", reachable, tt.want)
+			}
+			for _, id := range tt.want {
+				if !reachable[id] {
+					t.Errorf("room %s not reached, want %v", id, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// walkLevel finds the rooms a player reaches from the entrance, picking up
+// every key lying in a reached room and opening the doors it fits. The door
+// fitting skipKeyID is never opened.
+func walkLevel(entranceID string, connections []*game.RoomConnection, keys []*game.Item, skipKeyID string) map[string]bool {
+	reached := map[string]bool{entranceID: true}
+	held := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, key := range keys {
+			if reached[*key.RoomID] && !held[key.ID] {
+				held[key.ID] = true
+				changed = true
+			}
+		}
+		for _, conn := range connections {
+			if conn.IsLocked && (conn.KeyID == skipKeyID || !held[conn.KeyID]) {
+				continue
+			}
+			if reached[conn.RoomID] && !reached[conn.ConnectedRoomID] {
+				reached[conn.ConnectedRoomID] = true
+				changed = true
+			}
+		}
+	}
+	return reached
+}
+
+func TestPlaceLocksKeysReachable(t *testing.T) {
+	lockedLevels := 0
+	for seed := int64(1); seed <= 300; seed++ {
+		gen := NewLevelGenerator(seed, 1).WithSize(6, 6)
+		_, rooms, connections, err := gen.GenerateDungeon(1)
+		if err != nil {
+			t.Fatalf("seed %d: generating dungeon: %v", seed, err)
+		}
+		keys := gen.PlaceLocks(rooms, connections)
+		if len(keys) > MaxLockedDoors {
+			t.Errorf("seed %d: placed %d keys, want at most %d", seed, len(keys), MaxLockedDoors)
+		}
+		if len(keys) > 0 {
+			lockedLevels++
+		}
+
+		var entrance, exit *game.Room
+		byID := make(map[string]*game.Room)
+		for _, room := range rooms {
+			byID[room.ID] = room
+			if room.IsEntrance {
+				entrance = room
+			}
+			if room.IsExit {
+				exit = room
+			}
+		}
+
+		// Each locked door is locked both ways with its own key, and never
+		// leads out of the entrance
+		doorsPerKey := make(map[string]int)
+		for _, conn := range connections {
+			if !conn.IsLocked {
+				continue
+			}
+			doorsPerKey[conn.KeyID]++
+			if conn.RoomID == entrance.ID || conn.ConnectedRoomID == entrance.ID {
+				t.Errorf("seed %d: door %s -> %s at the entrance is locked", seed, conn.RoomID, conn.ConnectedRoomID)
+			}
+		}
+		for _, key := range keys {
+			if n := doorsPerKey[key.ID]; n != 2 {
+				t.Errorf("seed %d: key %s locks %d connections, want both directions of one door", seed, key.ID, n)
+			}
+		}
+		if len(doorsPerKey) != len(keys) {
+			t.Errorf("seed %d: %d doors locked for %d keys", seed, len(doorsPerKey), len(keys))
+		}
+
+		for _, key := range keys {
+			if key.RoomID == nil || byID[*key.RoomID] == nil {
+				t.Fatalf("seed %d: key %s is not lying in a room of the level", seed, key.ID)
+			}
+			if room := byID[*key.RoomID]; room.IsEntrance || room.IsExit {
+				t.Errorf("seed %d: key %s lies in the entrance or exit", seed, key.ID)
+			}
+
+			// The key can be fetched without going through the door it opens
+			if !walkLevel(entrance.ID, connections, keys, key.ID)[*key.RoomID] {
+				t.Errorf("seed %d: key %s is only reachable through its own door", seed, key.ID)
+			}
+		}
+
+		// Picking up keys along the way opens up the whole level
+		reached := walkLevel(entrance.ID, connections, keys, "")
+		if !reached[exit.ID] {
+			t.Errorf("seed %d: the exit cannot be reached", seed)
+		}
+		if len(reached) != len(rooms) {
+			t.Errorf("seed %d: reached %d of %d rooms", seed, len(reached), len(rooms))
+		}
+	}
+	if lockedLevels == 0 {
+		t.Error("no level had a locked door")
+	}
+}
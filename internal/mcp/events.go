@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// Notification methods pushed to streaming subscribers
+const (
+	NotificationGameState = notificationPrefix + "game/state"
+)
+
+// subscriberBuffer is how many notifications a slow subscriber may fall behind
+// before further notifications are dropped for it
+const subscriberBuffer = 32
+
+// Notification is a JSON-RPC 2.0 notification pushed to subscribers
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// GameStateParams is the payload of a game state notification
+type GameStateParams struct {
+	Tool      string                  `json:"tool,omitempty"` // tool that caused the change, empty for world events
+	Message   string                  `json:"message,omitempty"`
+	Event     *game.EventInfo         `json:"event,omitempty"`
+	GameState *game.GameStateSnapshot `json:"gameState"`
+}
+
+// StreamEvent is a single encoded notification with its sequence number
+type StreamEvent struct {
+	ID   uint64
+	Data []byte
+}
+
+// EventBroker fans out notifications to everyone watching a session
+type EventBroker struct {
+	mu          sync.Mutex
+	nextEventID uint64
+	subscribers map[string]map[chan StreamEvent]bool // session ID -> subscriber channels
+}
+
+// NewEventBroker creates an empty broker
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscribers: make(map[string]map[chan StreamEvent]bool),
+	}
+}
+
+// Subscribe registers interest in a session's notifications. The returned
+// cancel func must be called to release the subscription.
+func (b *EventBroker) Subscribe(sessionID string) (<-chan StreamEvent, func()) {
+	ch := make(chan StreamEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[sessionID] == nil {
+		b.subscribers[sessionID] = make(map[chan StreamEvent]bool)
+	}
+	b.subscribers[sessionID][ch] = true
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[sessionID], ch)
+			if len(b.subscribers[sessionID]) == 0 {
+				delete(b.subscribers, sessionID)
+			}
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Publish sends a notification to every subscriber of a session.
+// Subscribers whose buffers are full miss the notification rather than
+// blocking the game.
+func (b *EventBroker) Publish(sessionID string, n *Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subscribers[sessionID]
+	if len(subs) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return
+	}
+	b.nextEventID++
+	event := StreamEvent{ID: b.nextEventID, Data: data}
+
+	for ch := range subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// newGameStateNotification wraps a tool result as a game state notification
+func newGameStateNotification(tool string, result *ToolResult) *Notification {
+	params := &GameStateParams{
+		Tool:      tool,
+		GameState: result.GameState,
+	}
+	if len(result.Content) > 0 {
+		params.Message = result.Content[0].Text
+	}
+	if result.GameState != nil {
+		params.Event = result.GameState.Event
+	}
+	return &Notification{
+		JSONRPC: jsonRPCVersion,
+		Method:  NotificationGameState,
+		Params:  params,
+	}
+}
+
+// Subscribe streams game state notifications for a session
+func (s *Server) Subscribe(sessionID string) (<-chan StreamEvent, func()) {
+	return s.events.Subscribe(sessionID)
+}
+
+// Publish pushes a notification to a session's subscribers. World events
+// that happen outside a tool call use this directly.
+func (s *Server) Publish(sessionID string, n *Notification) {
+	s.events.Publish(sessionID, n)
+}
+
+// CurrentState returns a notification carrying a session's current snapshot,
+// sent to new subscribers so they start in sync. It returns nil if the
+// session has no game.
+func (s *Server) CurrentState(sessionID string) *Notification {
+	sess := s.resumeSession(sessionID)
+	if sess == nil {
+		return nil
+	}
+
+	sess.state.RLock()
+	defer sess.state.RUnlock()
+	if !sess.state.IsInitialized() {
+		return nil
+	}
+	return &Notification{
+		JSONRPC: jsonRPCVersion,
+		Method:  NotificationGameState,
+		Params: &GameStateParams{
+			GameState: sess.buildGameStateSnapshot(),
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"io"
+	"log"
+	"net/http"
+)
+
+// OpenSession registers a new, empty MCP session and returns its ID. The
+// session has no game until new_game is called in it.
+func (s *Server) OpenSession() string {
+	sessionID := NewSessionID()
+	s.sessions.GetOrCreate(sessionID)
+	return sessionID
+}
+
+// HasSession reports whether a session ID was issued by this server, resuming
+// its saved game if it is no longer in memory
+func (s *Server) HasSession(sessionID string) bool {
+	return s.resumeSession(sessionID) != nil
+}
+
+// ServeJSONRPC handles a streamable HTTP POST of JSON-RPC messages. A session
+// ID is issued on initialize and returned in the Mcp-Session-Id header; every
+// later request must send it back. Requests without one get 400 and requests
+// naming a session this server does not know get 404, telling the client to
+// initialize again.
+func (s *Server) ServeJSONRPC(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionID := r.Header.Get(MCPSessionHeader)
+	switch {
+	case IsInitializeRequest(payload):
+		sessionID = s.OpenSession()
+	case sessionID == "":
+		http.Error(w, "missing "+MCPSessionHeader+" header", http.StatusBadRequest)
+		return
+	case !s.HasSession(sessionID):
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	w.Header().Set(MCPSessionHeader, sessionID)
+
+	response := s.HandleJSONRPC(sessionID, payload)
+	if response == nil {
+		// Only notifications or responses were sent
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(response); err != nil {
+		log.Printf("Error writing JSON-RPC response: %v", err)
+	}
+}
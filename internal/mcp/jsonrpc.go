@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MCP protocol constants
+const (
+	ProtocolVersion    = "2025-06-18"
+	ServerName         = "dungeon-crawler"
+	ServerVersion      = "1.0.0"
+	MCPSessionHeader   = "Mcp-Session-Id"
+	jsonRPCVersion     = "2.0"
+	methodInitialize   = "initialize"
+	methodPing         = "ping"
+	methodToolsList    = "tools/list"
+	methodToolsCall    = "tools/call"
+	notificationPrefix = "notifications/"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// supportedProtocolVersions lists MCP revisions this server can speak
+var supportedProtocolVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// RPCRequest is a JSON-RPC 2.0 request or notification
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// isNotification returns true if the request has no ID and expects no response
+func (r *RPCRequest) isNotification() bool {
+	return r.ID == nil
+}
+
+// RPCResponse is a JSON-RPC 2.0 response
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// nullID is used for errors where the request ID could not be determined
+var nullID = json.RawMessage("null")
+
+// newRPCError builds an error response
+func newRPCError(id json.RawMessage, code int, message string) *RPCResponse {
+	if id == nil {
+		id = nullID
+	}
+	return &RPCResponse{
+		JSONRPC: jsonRPCVersion,
+		ID:      id,
+		Error:   &RPCError{Code: code, Message: message},
+	}
+}
+
+// newRPCResult builds a success response
+func newRPCResult(id json.RawMessage, result interface{}) *RPCResponse {
+	return &RPCResponse{
+		JSONRPC: jsonRPCVersion,
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// IsInitializeRequest reports whether a payload is an MCP initialize request.
+// Transports use this to decide when to assign a new session ID.
+func IsInitializeRequest(payload []byte) bool {
+	var req RPCRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return false
+	}
+	return req.Method == methodInitialize
+}
+
+// HandleJSONRPC processes a JSON-RPC 2.0 payload (a single message or a batch)
+// for the given session and returns the encoded response. It returns nil when
+// the payload contained only notifications and no response should be sent.
+func (s *Server) HandleJSONRPC(sessionID string, payload []byte) []byte {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 {
+		return encodeRPC(newRPCError(nil, ErrCodeInvalidRequest, "empty request"))
+	}
+
+	// Batch request
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return encodeRPC(newRPCError(nil, ErrCodeParse, "parse error"))
+		}
+		if len(batch) == 0 {
+			return encodeRPC(newRPCError(nil, ErrCodeInvalidRequest, "empty batch"))
+		}
+
+		responses := make([]*RPCResponse, 0, len(batch))
+		for _, msg := range batch {
+			if resp := s.handleRPCMessage(sessionID, msg); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return encodeRPC(responses)
+	}
+
+	resp := s.handleRPCMessage(sessionID, trimmed)
+	if resp == nil {
+		return nil
+	}
+	return encodeRPC(resp)
+}
+
+// encodeRPC marshals a response (or batch of responses)
+func encodeRPC(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(newRPCError(nil, ErrCodeInternal, "failed to encode response"))
+	}
+	return data
+}
+
+// handleRPCMessage processes a single JSON-RPC message
+func (s *Server) handleRPCMessage(sessionID string, msg json.RawMessage) *RPCResponse {
+	var req RPCRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return newRPCError(nil, ErrCodeParse, "parse error")
+	}
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		if req.isNotification() {
+			return nil
+		}
+		return newRPCError(req.ID, ErrCodeInvalidRequest, "invalid request")
+	}
+
+	// Notifications (initialized, cancelled, ...) need no response
+	if req.isNotification() {
+		return nil
+	}
+
+	switch req.Method {
+	case methodInitialize:
+		return s.handleRPCInitialize(&req)
+	case methodPing:
+		return newRPCResult(req.ID, struct{}{})
+	case methodToolsList:
+		return newRPCResult(req.ID, map[string]interface{}{
+			"tools": s.ListTools(),
+		})
+	case methodToolsCall:
+		return s.handleRPCToolsCall(sessionID, &req)
+	default:
+		return newRPCError(req.ID, ErrCodeMethodNotFound, "method not found: "+req.Method)
+	}
+}
+
+// handleRPCInitialize negotiates the protocol version and advertises capabilities
+func (s *Server) handleRPCInitialize(req *RPCRequest) *RPCResponse {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return newRPCError(req.ID, ErrCodeInvalidParams, "invalid initialize params")
+		}
+	}
+
+	// Echo the client's version if we support it, otherwise offer ours
+	version := ProtocolVersion
+	if supportedProtocolVersions[params.ProtocolVersion] {
+		version = params.ProtocolVersion
+	}
+
+	return newRPCResult(req.ID, map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{
+				"listChanged": false,
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    ServerName,
+			"version": ServerVersion,
+		},
+	})
+}
+
+// handleRPCToolsCall runs a tool through CallTool
+func (s *Server) handleRPCToolsCall(sessionID string, req *RPCRequest) *RPCResponse {
+	var params struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+		return newRPCError(req.ID, ErrCodeInvalidParams, "tools/call requires a tool name")
+	}
+	if params.Arguments == nil {
+		params.Arguments = make(map[string]interface{})
+	}
+
+	result, err := s.CallTool(sessionID, params.Name, params.Arguments)
+	if err != nil {
+		return newRPCError(req.ID, ErrCodeInvalidParams, err.Error())
+	}
+	return newRPCResult(req.ID, result)
+}
@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postRPC sends a JSON-RPC payload to ServeJSONRPC in the given session
+func postRPC(s *Server, sessionID, payload string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(MCPSessionHeader, sessionID)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeJSONRPC(rec, req)
+	return rec
+}
+
+// initializeSession runs initialize and returns the issued session ID
+func initializeSession(t *testing.T, s *Server) string {
+	t.Helper()
+	rec := postRPC(s, "", `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-03-26"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("initialize: status %d: %s", rec.Code, rec.Body)
+	}
+	sessionID := rec.Header().Get(MCPSessionHeader)
+	if sessionID == "" {
+		t.Fatal("initialize did not return a session ID")
+	}
+	return sessionID
+}
+
+// decodeRPC decodes a single JSON-RPC response
+func decodeRPC(t *testing.T, rec *httptest.ResponseRecorder) RPCResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp RPCResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %s: %v", rec.Body, err)
+	}
+	return resp
+}
+
+func TestRPCInitialize(t *testing.T) {
+	s := NewServer(nil)
+	rec := postRPC(s, "", `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-03-26"}}`)
+	resp := decodeRPC(t, rec)
+	if resp.Error != nil {
+		t.Fatalf("initialize failed: %s", resp.Error.Message)
+	}
+
+	result := resp.Result.(map[string]interface{})
+	if v := result["protocolVersion"]; v != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's 2025-03-26", v)
+	}
+	sessionID := rec.Header().Get(MCPSessionHeader)
+	if !s.sessions.Has(sessionID) {
+		t.Errorf("initialize returned session %q but did not register it", sessionID)
+	}
+
+	// Each initialize starts its own session
+	if other := initializeSession(t, s); other == sessionID {
+		t.Errorf("two initialize requests shared session %s", sessionID)
+	}
+}
+
+func TestRPCSessionRequired(t *testing.T) {
+	s := NewServer(nil)
+	list := `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`
+
+	if rec := postRPC(s, "", list); rec.Code != http.StatusBadRequest {
+		t.Errorf("request without a session: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := postRPC(s, "unknown", list); rec.Code != http.StatusNotFound {
+		t.Errorf("request with an unknown session: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	newGame := `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "new_game", "arguments": {}}}`
+	if rec := postRPC(s, "unknown", newGame); rec.Code != http.StatusNotFound {
+		t.Errorf("new_game in an unknown session: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if s.sessions.Has("unknown") {
+		t.Error("a rejected request created its session")
+	}
+}
+
+func TestRPCToolsList(t *testing.T) {
+	s := NewServer(nil)
+	sessionID := initializeSession(t, s)
+
+	resp := decodeRPC(t, postRPC(s, sessionID, `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`))
+	if resp.Error != nil {
+		t.Fatalf("tools/list failed: %s", resp.Error.Message)
+	}
+	tools, _ := resp.Result.(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != len(s.ListTools()) {
+		t.Errorf("tools/list returned %d tools, want %d", len(tools), len(s.ListTools()))
+	}
+}
+
+func TestRPCToolsCall(t *testing.T) {
+	s := NewServer(nil)
+	sessionID := initializeSession(t, s)
+
+	rec := postRPC(s, sessionID, `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "new_game", "arguments": {"seed": 42}}}`)
+	resp := decodeRPC(t, rec)
+	if resp.Error != nil {
+		t.Fatalf("new_game failed: %s", resp.Error.Message)
+	}
+	if got := rec.Header().Get(MCPSessionHeader); got != sessionID {
+		t.Errorf("new_game answered in session %q, want %q", got, sessionID)
+	}
+	if got := resp.Result.(map[string]interface{})["sessionId"]; got != sessionID {
+		t.Errorf("new_game result names session %v, want %s", got, sessionID)
+	}
+
+	sess, ok := s.sessions.Get(sessionID)
+	if !ok || !sess.state.IsInitialized() {
+		t.Fatal("new_game did not start a game in the initialized session")
+	}
+
+	resp = decodeRPC(t, postRPC(s, sessionID, `{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "look"}}`))
+	if resp.Error != nil {
+		t.Fatalf("look failed: %s", resp.Error.Message)
+	}
+	if sess.state.TurnNumber != 1 {
+		t.Errorf("look left the game on turn %d, want 1", sess.state.TurnNumber)
+	}
+}
+
+func TestRPCBatch(t *testing.T) {
+	s := NewServer(nil)
+	sessionID := initializeSession(t, s)
+
+	rec := postRPC(s, sessionID, `[
+		{"jsonrpc": "2.0", "id": 1, "method": "ping"},
+		{"jsonrpc": "2.0", "method": "notifications/initialized"},
+		{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}
+	]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var batch []RPCResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &batch); err != nil {
+		t.Fatalf("decoding batch response %s: %v", rec.Body, err)
+	}
+	if len(batch) != 2 {
+		t.Fatalf("batch returned %d responses, want one per request and none for the notification", len(batch))
+	}
+	for i, want := range []string{"1", "2"} {
+		if got := string(batch[i].ID); got != want || batch[i].Error != nil {
+			t.Errorf("response %d: id %s, error %v; want id %s and no error", i, got, batch[i].Error, want)
+		}
+	}
+}
+
+func TestRPCNotificationHasNoBody(t *testing.T) {
+	s := NewServer(nil)
+	sessionID := initializeSession(t, s)
+
+	for _, payload := range []string{
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		`[{"jsonrpc": "2.0", "method": "notifications/initialized"}, {"jsonrpc": "2.0", "method": "notifications/cancelled"}]`,
+	} {
+		rec := postRPC(s, sessionID, payload)
+		if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+			t.Errorf("%s: status %d with body %q, want %d and no body", payload, rec.Code, rec.Body, http.StatusAccepted)
+		}
+	}
+}
+
+func TestRPCErrorCodes(t *testing.T) {
+	s := NewServer(nil)
+	sessionID := initializeSession(t, s)
+
+	tests := []struct {
+		name    string
+		payload string
+		code    int
+	}{
+		{"malformed JSON", `{"jsonrpc": "2.0", "id": 1,`, ErrCodeParse},
+		{"empty batch", `[]`, ErrCodeInvalidRequest},
+		{"wrong version", `{"jsonrpc": "1.0", "id": 1, "method": "ping"}`, ErrCodeInvalidRequest},
+		{"missing method", `{"jsonrpc": "2.0", "id": 1}`, ErrCodeInvalidRequest},
+		{"unknown method", `{"jsonrpc": "2.0", "id": 1, "method": "resources/list"}`, ErrCodeMethodNotFound},
+		{"tools/call without a name", `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {}}`, ErrCodeInvalidParams},
+		{"unknown tool", `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "fly"}}`, ErrCodeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeRPC(t, postRPC(s, sessionID, tt.payload))
+			if resp.Error == nil {
+				t.Fatalf("no error, result %v", resp.Result)
+			}
+			if resp.Error.Code != tt.code {
+				t.Errorf("error code %d (%s), want %d", resp.Error.Code, resp.Error.Message, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// ReplayVersion is the current replay file format version. It changes
+// whenever the same seed and actions would build a different game.
+const ReplayVersion = 14
+
+// Replay is the exportable record of a game: its seed, ID prefix and every
+// accepted tool call, starting with new_game. Replaying the actions from the
+// seed rebuilds the game; StateHash identifies the state it should reach.
+type Replay struct {
+	Version   int                 `json:"version"`
+	Seed      int64               `json:"seed"`
+	IDPrefix  string              `json:"idPrefix"`
+	Actions   []game.ActionRecord `json:"actions"`
+	StateHash string              `json:"stateHash"`
+}
+
+// transientSnapshotFields describe only the last turn. They are not saved
+// with the game, so they are left out of the hash to keep a game exported
+// after a server restart verifiable.
+var transientSnapshotFields = []string{
+	"message", "event", "combatResult", "inventoryDelta", "arrivals", "isFirstVisit", "isNew", "isDefeated",
+}
+
+// snapshotHash fingerprints a snapshot. The game's ID prefix is stripped so a
+// replay (which gets a fresh prefix) hashes the same as the original.
+func snapshotHash(snapshot *game.GameStateSnapshot, idPrefix string) string {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return ""
+	}
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ""
+	}
+	stripTransientFields(fields)
+	if data, err = json.Marshal(fields); err != nil {
+		return ""
+	}
+
+	canonical := string(data)
+	if idPrefix != "" {
+		canonical = strings.ReplaceAll(canonical, idPrefix, "")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(canonical)))
+}
+
+// stripTransientFields removes transientSnapshotFields at every level of a
+// decoded JSON value
+func stripTransientFields(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, field := range transientSnapshotFields {
+			delete(v, field)
+		}
+		for _, child := range v {
+			stripTransientFields(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			stripTransientFields(child)
+		}
+	}
+}
+
+// replayArgument accepts a replay either as a JSON string or an inline object
+func replayArgument(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid replay")
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("invalid replay")
+	}
+}
+
+// rewriteIDs replaces one ID prefix with another in every string argument
+func rewriteIDs(arguments map[string]interface{}, from, to string) map[string]interface{} {
+	rewritten := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		if str, ok := v.(string); ok && from != "" && strings.HasPrefix(str, from) {
+			v = to + strings.TrimPrefix(str, from)
+		}
+		rewritten[k] = v
+	}
+	return rewritten
+}
+
+// handleExportReplay returns the current game as a replay file
+func (s *Session) handleExportReplay() (*ToolResult, error) {
+	if errResult := s.requireInitialized(); errResult != nil {
+		return errResult, nil
+	}
+
+	replay := &Replay{
+		Version:   ReplayVersion,
+		Seed:      s.state.Dice.Seed(),
+		IDPrefix:  s.state.IDPrefix,
+		Actions:   s.state.ActionLog,
+		StateHash: snapshotHash(s.buildGameStateSnapshot(), s.state.IDPrefix),
+	}
+	data, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode replay: %w", err)
+	}
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: string(data)}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleReplay rebuilds a game from a replay file in this session and checks
+// that it reaches the recorded state
+func (s *Session) handleReplay(data string) (*ToolResult, error) {
+	var replay Replay
+	if err := json.Unmarshal([]byte(data), &replay); err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Invalid replay file: %v", err)}},
+			IsError: true,
+		}, nil
+	}
+	if replay.Version != ReplayVersion {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Unsupported replay version %d (expected %d).", replay.Version, ReplayVersion)}},
+			IsError: true,
+		}, nil
+	}
+	if len(replay.Actions) == 0 || replay.Actions[0].Tool != "new_game" {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "Invalid replay file: it must start with new_game."}},
+			IsError: true,
+		}, nil
+	}
+
+	// Rebuild under a fresh ID prefix so the copy never collides with the
+	// original game if both are saved to the same database
+	idPrefix := game.NewIDPrefix()
+	first := replay.Actions[0]
+	if _, err := s.handleNewGame(parseNewGameOptions(first.Arguments), replay.Seed, idPrefix); err != nil {
+		return nil, err
+	}
+	s.state.RecordAction(first.Tool, first.Arguments)
+
+	for i, action := range replay.Actions[1:] {
+		args := rewriteIDs(action.Arguments, replay.IDPrefix, idPrefix)
+		if _, err := s.callTool(action.Tool, args); err != nil {
+			return &ToolResult{
+				Content:   []ContentBlock{{Type: "text", Text: fmt.Sprintf("Replay failed at action %d (%s): %v", i+2, action.Tool, err)}},
+				IsError:   true,
+				GameState: s.buildGameStateSnapshot(),
+			}, nil
+		}
+	}
+
+	snapshot := s.buildGameStateSnapshot()
+	hash := snapshotHash(snapshot, idPrefix)
+
+	var message string
+	switch {
+	case replay.StateHash == "":
+		message = fmt.Sprintf("Replayed %d actions. The replay file has no state hash, so the result could not be verified.", len(replay.Actions))
+	case hash == replay.StateHash:
+		message = fmt.Sprintf("Replay verified: %d actions reproduce the recorded game state.", len(replay.Actions))
+	default:
+		message = fmt.Sprintf("Replay diverged: after %d actions the game state does not match the recording (expected %s, got %s).",
+			len(replay.Actions), replay.StateHash, hash)
+	}
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: message}},
+		GameState: snapshot,
+	}, nil
+}
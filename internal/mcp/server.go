@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"fmt"
+	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,18 +11,54 @@ import (
 	"github.com/yourusername/dungeon-crawler/internal/generator"
 )
 
+// Store persists game state so sessions survive a server restart
+type Store interface {
+	// SaveGame writes the full game state for a session
+	SaveGame(sessionID string, gs *game.GameState) error
+	// LoadGame rebuilds a session's game state, returning nil if none was saved
+	LoadGame(sessionID string) (*game.GameState, error)
+}
+
 // Server implements the MCP protocol for the dungeon crawler
 type Server struct {
-	state *game.GameState
+	sessions *SessionManager
+	store    Store
+	events   *EventBroker
 }
 
-// NewServer creates a new MCP server instance
-func NewServer() *Server {
+// NewServer creates a new MCP server instance.
+// store may be nil, in which case games only live in memory.
+func NewServer(store Store) *Server {
 	return &Server{
-		state: game.NewGameState(),
+		sessions: NewSessionManager(),
+		store:    store,
+		events:   NewEventBroker(),
 	}
 }
 
+// readOnlyTools lists tools that never change game state. They run under the
+// game's read lock and skip saving; every other tool takes the write lock.
+var readOnlyTools = map[string]bool{
+	"inventory":     true,
+	"stats":         true,
+	"map":           true,
+	"shop":          true,
+	"export_replay": true,
+}
+
+// sessionStartingTools build a new game, creating the session (and its ID if
+// none was given) rather than requiring an existing one
+var sessionStartingTools = map[string]bool{
+	"new_game": true,
+	"replay":   true,
+}
+
+// unloggedTools lists state-changing tools that are not recorded in the
+// action log (replay re-records the actions it runs)
+var unloggedTools = map[string]bool{
+	"replay": true,
+}
+
 // Tool represents an MCP tool definition
 type Tool struct {
 	Name        string      `json:"name"`
@@ -33,6 +71,7 @@ type ToolResult struct {
 	Content   []ContentBlock          `json:"content"`
 	IsError   bool                    `json:"isError,omitempty"`
 	GameState *game.GameStateSnapshot `json:"gameState,omitempty"`
+	SessionID string                  `json:"sessionId,omitempty"`
 }
 
 // ContentBlock represents a content block in the result
@@ -42,7 +81,7 @@ type ContentBlock struct {
 }
 
 // calculateThreat determines monster threat level relative to player
-func (s *Server) calculateThreat(monster *game.Monster, player *game.Character) string {
+func (s *Session) calculateThreat(monster *game.Monster, player *game.Character) string {
 	if player == nil || monster == nil {
 		return "normal"
 	}
@@ -65,8 +104,9 @@ func (s *Server) calculateThreat(monster *game.Monster, player *game.Character)
 }
 
 // calculateAtmosphere determines room atmosphere based on threats and location
-func (s *Server) calculateAtmosphere(room *game.Room, monsters []*game.Monster, player *game.Character) string {
-	distance := room.X + room.Y // Manhattan distance
+func (s *Session) calculateAtmosphere(room *game.Room, monsters []*game.Monster, player *game.Character) string {
+	distance := s.state.DistanceFromEntrance(room)
+	progress := s.state.LevelProgress(room)
 
 	// Check for dangerous/deadly monsters
 	hasDangerousMonster := false
@@ -82,14 +122,14 @@ func (s *Server) calculateAtmosphere(room *game.Room, monsters []*game.Monster,
 		if distance <= 1 {
 			return "safe"
 		}
-		if distance >= 6 {
+		if progress >= 0.75 {
 			return "mysterious"
 		}
 		return "tense"
 	}
 
 	if hasDangerousMonster {
-		if distance >= 6 {
+		if progress >= 0.75 {
 			return "ominous"
 		}
 		return "dangerous"
@@ -98,40 +138,42 @@ func (s *Server) calculateAtmosphere(room *game.Room, monsters []*game.Monster,
 	return "tense"
 }
 
-// calculatePhase determines game phase based on room position
-func (s *Server) calculatePhase(room *game.Room) string {
+// calculatePhase determines game phase based on how far the room is
+// between the entrance and the exit
+func (s *Session) calculatePhase(room *game.Room) string {
 	if room == nil {
 		return "early_game"
 	}
-	distance := room.X + room.Y
 	if room.IsExit {
 		return "exit"
 	}
-	if distance <= 2 {
+	progress := s.state.LevelProgress(room)
+	if progress <= 0.25 {
 		return "early_game"
 	}
-	if distance <= 5 {
+	if progress <= 0.65 {
 		return "mid_game"
 	}
 	return "late_game"
 }
 
 // calculateExplorationPct calculates percentage of dungeon explored
-func (s *Server) calculateExplorationPct() float64 {
-	if len(s.state.Rooms) == 0 {
-		return 0
-	}
-	visited := 0
-	for roomID := range s.state.Rooms {
-		if s.state.IsRoomVisited(roomID) {
-			visited++
-		}
+func (s *Session) calculateExplorationPct() float64 {
+	return s.state.ExplorationPct()
+}
+
+// scoreSummary describes the final score of a finished run, ending in a blank line
+func (s *Session) scoreSummary() string {
+	score := s.state.Score
+	if score == nil {
+		return ""
 	}
-	return float64(visited) / float64(len(s.state.Rooms)) * 100
+	return fmt.Sprintf("Final score: %d (depth %d, %d kills, %d gold, %d turns, %.0f%% explored)\n\n",
+		score.Total, score.Depth, score.Kills, score.Gold, score.Turns, score.Exploration)
 }
 
 // isItemNew checks if an item was just discovered this turn
-func (s *Server) isItemNew(itemID string) bool {
+func (s *Session) isItemNew(itemID string) bool {
 	if s.state.TurnContext == nil {
 		return false
 	}
@@ -144,7 +186,7 @@ func (s *Server) isItemNew(itemID string) bool {
 }
 
 // isMonsterDefeated checks if a monster was defeated this turn
-func (s *Server) isMonsterDefeated(monsterID string) bool {
+func (s *Session) isMonsterDefeated(monsterID string) bool {
 	if s.state.TurnContext == nil {
 		return false
 	}
@@ -167,7 +209,7 @@ const (
 
 // requireActiveGame checks if a game is in progress and not over.
 // Returns a ToolResult with an error message if the game is not active, or nil if OK.
-func (s *Server) requireActiveGame() *ToolResult {
+func (s *Session) requireActiveGame() *ToolResult {
 	if !s.state.IsInitialized() {
 		return &ToolResult{
 			Content: []ContentBlock{{Type: "text", Text: errNoGame}},
@@ -188,7 +230,7 @@ func (s *Server) requireActiveGame() *ToolResult {
 
 // requireActiveGameForAction is like requireActiveGame but uses a simpler "game over" message
 // suitable for actions that don't need to distinguish between victory and death.
-func (s *Server) requireActiveGameForAction() *ToolResult {
+func (s *Session) requireActiveGameForAction() *ToolResult {
 	if !s.state.IsInitialized() {
 		return &ToolResult{
 			Content: []ContentBlock{{Type: "text", Text: errNoGame}},
@@ -203,7 +245,7 @@ func (s *Server) requireActiveGameForAction() *ToolResult {
 }
 
 // requireInitialized checks only if a game is initialized (for read-only operations like inventory/stats).
-func (s *Server) requireInitialized() *ToolResult {
+func (s *Session) requireInitialized() *ToolResult {
 	if !s.state.IsInitialized() {
 		return &ToolResult{
 			Content: []ContentBlock{{Type: "text", Text: errNoGame}},
@@ -212,35 +254,131 @@ func (s *Server) requireInitialized() *ToolResult {
 	return nil
 }
 
+// acceptAction marks the current tool call as carried out, so it is recorded
+// in the action log. Every turn is accepted; actions that take no turn, like
+// equip, accept themselves once they succeed.
+func (s *Session) acceptAction() {
+	s.accepted = true
+}
+
 // beginTurn resets turn context and increments turn counters for a standard action.
-func (s *Server) beginTurn() {
+func (s *Session) beginTurn() {
+	s.acceptAction()
 	s.state.ResetTurnContext()
+	s.state.IncrementTurn()
 	s.state.IncrementTurnsInRoom()
 }
 
 // beginCombatTurn resets turn context and increments both room and combat counters.
-func (s *Server) beginCombatTurn() {
+func (s *Session) beginCombatTurn() {
+	s.acceptAction()
 	s.state.ResetTurnContext()
+	s.state.IncrementTurn()
 	s.state.IncrementTurnsInRoom()
 	s.state.IncrementConsecutiveCombat()
 }
 
 // beginMovementTurn resets turn context and resets room/combat counters for movement.
-func (s *Server) beginMovementTurn() {
+func (s *Session) beginMovementTurn() {
+	s.acceptAction()
 	s.state.ResetTurnContext()
+	s.state.IncrementTurn()
 	s.state.ResetTurnsInRoom()
 	s.state.ResetConsecutiveCombat()
 }
 
+// abilityViews lists the abilities the character knows for the frontend
+func (s *Session) abilityViews() []*game.AbilityView {
+	known := s.state.Character.KnownAbilities()
+	views := make([]*game.AbilityView, 0, len(known))
+	for _, name := range known {
+		ability := game.Abilities[name]
+		views = append(views, &game.AbilityView{
+			ID:          name,
+			Name:        ability.Name,
+			Description: ability.Description,
+			Kind:        ability.Kind,
+			ManaCost:    ability.ManaCost,
+			CanCast:     s.state.CanCast(name),
+		})
+	}
+	return views
+}
+
+// effectViews converts status effects for the frontend
+func effectViews(effects []game.StatusEffect) []*game.EffectView {
+	views := make([]*game.EffectView, 0, len(effects))
+	for _, e := range effects {
+		views = append(views, &game.EffectView{
+			Type:        e.Type,
+			Potency:     e.Potency,
+			Duration:    e.Duration,
+			Description: e.Describe(),
+		})
+	}
+	return views
+}
+
+// itemEffect describes the status effect a consumable applies, if any
+func itemEffect(item *game.Item) string {
+	if item.Effect == nil {
+		return ""
+	}
+	return item.Effect.Describe()
+}
+
+// rarityTag labels items better than common, e.g. " (rare)"
+func rarityTag(item *game.Item) string {
+	if item.Rarity == "" || item.Rarity == "common" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", item.Rarity)
+}
+
+// itemOnHit describes the status effect a weapon's hits may inflict, if any
+func itemOnHit(item *game.Item) string {
+	if item.OnHit == nil {
+		return ""
+	}
+	return item.OnHit.Describe()
+}
+
+// itemView converts an item for the frontend
+func (s *Session) itemView(item *game.Item) *game.ItemView {
+	return &game.ItemView{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		Type:        item.Type,
+		Damage:      item.Damage,
+		Armor:       item.Armor,
+		Healing:     item.Healing,
+		Rarity:      item.Rarity,
+		Value:       item.Value,
+		IsEquipped:  item.IsEquipped,
+		IsNew:       s.isItemNew(item.ID),
+		Effect:      itemEffect(item),
+		Cures:       item.Cures,
+		OnHit:       itemOnHit(item),
+	}
+}
+
 // buildGameStateSnapshot creates a snapshot of the current game state for the frontend
-func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
+func (s *Session) buildGameStateSnapshot() *game.GameStateSnapshot {
 	if !s.state.IsInitialized() {
 		return nil
 	}
 
 	snapshot := &game.GameStateSnapshot{
-		GameOver: s.state.GameOver,
-		Victory:  s.state.Victory,
+		GameOver:   s.state.GameOver,
+		Victory:    s.state.Victory,
+		TurnNumber: s.state.TurnNumber,
+		Score:      s.state.Score,
+	}
+	if s.state.Dungeon != nil {
+		snapshot.Seed = s.state.Dungeon.Seed
+		snapshot.Depth = s.state.Dungeon.Depth
+		snapshot.FinalDepth = s.state.FinalDepth
 	}
 
 	// Character view
@@ -256,14 +394,27 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 		}
 
 		snapshot.Character = &game.CharacterView{
-			ID:        char.ID,
-			Name:      char.Name,
-			HP:        char.HP,
-			MaxHP:     char.MaxHP,
-			Strength:  char.Strength,
-			Dexterity: char.Dexterity,
-			IsAlive:   char.IsAlive,
-			Status:    status,
+			ID:         char.ID,
+			Name:       char.Name,
+			Class:      char.Class,
+			HP:         char.HP,
+			MaxHP:      char.MaxHP,
+			Strength:   char.Strength,
+			Dexterity:  char.Dexterity,
+			IsAlive:    char.IsAlive,
+			Status:     status,
+			Gold:       char.Gold,
+			Level:      char.Level,
+			XP:         char.XP,
+			XPToLevel:  game.XPForLevel(char.Level + 1),
+			StatPoints: char.StatPoints,
+			Capacity:   char.CarryCapacity(),
+			FreeSlots:  s.state.FreeSlots(),
+			Mana:       char.Mana,
+			MaxMana:    char.MaxMana,
+			Shield:     char.Shield,
+			Abilities:  s.abilityViews(),
+			Effects:    effectViews(char.Effects),
 		}
 	}
 
@@ -271,11 +422,7 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 	room := s.state.GetCurrentRoom()
 	monsters := make([]*game.Monster, 0)
 	if room != nil {
-		exits := s.state.GetRoomExits(room.ID)
-		exitDirs := make([]string, 0, len(exits))
-		for dir := range exits {
-			exitDirs = append(exitDirs, dir)
-		}
+		exitDirs := s.state.GetExitDirections(room.ID)
 
 		monsters = s.state.GetRoomMonsters(room.ID)
 
@@ -298,6 +445,18 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 			Exits:        exitDirs,
 			Atmosphere:   s.calculateAtmosphere(room, monsters, s.state.Character),
 			IsFirstVisit: isFirstVisit,
+			LockedExits:  s.state.GetLockedExits(room.ID),
+		}
+
+		// Only traps the player has found are shown
+		for _, t := range s.state.GetDiscoveredTraps(room.ID) {
+			snapshot.CurrentRoom.Traps = append(snapshot.CurrentRoom.Traps, &game.TrapView{
+				ID:          t.ID,
+				Name:        t.Name,
+				Description: t.Description,
+				Damage:      t.Damage,
+				Difficulty:  t.Difficulty,
+			})
 		}
 
 		// Monsters in current room
@@ -312,6 +471,7 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 				Damage:      m.Damage,
 				Threat:      s.calculateThreat(m, s.state.Character),
 				IsDefeated:  s.isMonsterDefeated(m.ID),
+				Effects:     effectViews(m.Effects),
 			})
 		}
 
@@ -319,18 +479,23 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 		roomItems := s.state.GetRoomItems(room.ID)
 		snapshot.RoomItems = make([]*game.ItemView, 0, len(roomItems))
 		for _, item := range roomItems {
-			snapshot.RoomItems = append(snapshot.RoomItems, &game.ItemView{
-				ID:          item.ID,
-				Name:        item.Name,
-				Description: item.Description,
-				Type:        item.Type,
-				Damage:      item.Damage,
-				Armor:       item.Armor,
-				Healing:     item.Healing,
-				Rarity:      item.Rarity,
-				IsEquipped:  item.IsEquipped,
-				IsNew:       s.isItemNew(item.ID),
-			})
+			snapshot.RoomItems = append(snapshot.RoomItems, s.itemView(item))
+		}
+
+		// The merchant's stock, priced for a shop panel
+		if merchant := s.state.GetRoomMerchant(room.ID); merchant != nil {
+			snapshot.Merchant = &game.MerchantView{
+				ID:          merchant.ID,
+				Name:        merchant.Name,
+				Description: merchant.Description,
+				Stock:       make([]*game.ItemView, 0, len(merchant.Stock)),
+				CanTrade:    len(monsters) == 0,
+			}
+			for _, item := range merchant.Stock {
+				view := s.itemView(item)
+				view.Price = game.BuyPrice(item)
+				snapshot.Merchant.Stock = append(snapshot.Merchant.Stock, view)
+			}
 		}
 	}
 
@@ -338,18 +503,12 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 	inventory := s.state.GetInventory()
 	snapshot.Inventory = make([]*game.ItemView, 0, len(inventory))
 	for _, item := range inventory {
-		snapshot.Inventory = append(snapshot.Inventory, &game.ItemView{
-			ID:          item.ID,
-			Name:        item.Name,
-			Description: item.Description,
-			Type:        item.Type,
-			Damage:      item.Damage,
-			Armor:       item.Armor,
-			Healing:     item.Healing,
-			Rarity:      item.Rarity,
-			IsEquipped:  item.IsEquipped,
-			IsNew:       s.isItemNew(item.ID),
-		})
+		view := s.itemView(item)
+		// With a merchant at hand, show what each item would sell for
+		if snapshot.Merchant != nil && game.IsTradeable(item) {
+			view.Price = game.SellPrice(item)
+		}
+		snapshot.Inventory = append(snapshot.Inventory, view)
 	}
 
 	// Equipment
@@ -390,22 +549,24 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 		snapshot.Event = s.state.TurnContext.LastEvent
 		snapshot.CombatResult = s.state.TurnContext.LastCombatResult
 		snapshot.InventoryDelta = s.state.TurnContext.InventoryDelta
+		snapshot.Arrivals = s.state.TurnContext.ArrivedMonsters
 
 		// Game context
 		snapshot.Context = &game.GameContext{
 			Phase:             s.calculatePhase(room),
+			Depth:             s.state.Dungeon.Depth,
 			TurnsInRoom:       s.state.TurnContext.TurnsInRoom,
 			ConsecutiveCombat: s.state.TurnContext.ConsecutiveCombat,
 			ExplorationPct:    s.calculateExplorationPct(),
 		}
 	}
 
-	// Map grid
-	gridSize := generator.GridSize
-	snapshot.MapGrid = make([][]game.MapCell, gridSize)
-	for y := 0; y < gridSize; y++ {
-		snapshot.MapGrid[y] = make([]game.MapCell, gridSize)
-		for x := 0; x < gridSize; x++ {
+	// Map grid, sized to the dungeon (rows by y, then columns by x)
+	width, height := s.state.Dungeon.Width, s.state.Dungeon.Height
+	snapshot.MapGrid = make([][]game.MapCell, height)
+	for y := 0; y < height; y++ {
+		snapshot.MapGrid[y] = make([]game.MapCell, width)
+		for x := 0; x < width; x++ {
 			cell := game.MapCell{
 				X:      x,
 				Y:      y,
@@ -417,21 +578,20 @@ func (s *Server) buildGameStateSnapshot() *game.GameStateSnapshot {
 				cell.RoomID = mapRoom.ID
 
 				// Get exits for this room
-				exits := s.state.GetRoomExits(mapRoom.ID)
-				cell.Exits = make([]string, 0, len(exits))
-				for dir := range exits {
-					cell.Exits = append(cell.Exits, dir)
-				}
+				cell.Exits = s.state.GetExitDirections(mapRoom.ID)
+				cell.LockedExits = s.state.GetLockedExits(mapRoom.ID)
 
 				if room != nil && mapRoom.ID == room.ID {
 					cell.Status = "current"
 					cell.HasPlayer = true
-				} else if mapRoom.IsExit && s.state.IsRoomVisited(mapRoom.ID) {
+				} else if mapRoom.IsExit && (s.state.IsRoomVisited(mapRoom.ID) || s.state.IsRoomRevealed(mapRoom.ID)) {
 					cell.Status = "exit"
 				} else if s.state.IsRoomVisited(mapRoom.ID) {
 					cell.Status = "visited"
 				} else if s.state.IsRoomAdjacent(mapRoom.ID) {
 					cell.Status = "adjacent"
+				} else if s.state.IsRoomRevealed(mapRoom.ID) {
+					cell.Status = "revealed"
 				}
 			}
 
@@ -455,6 +615,42 @@ func (s *Server) ListTools() []Tool {
 						"type":        "string",
 						"description": "Name of your character",
 					},
+					"class": map[string]interface{}{
+						"type":        "string",
+						"description": "Character class: adventurer (default), warrior, rogue or mage",
+						"enum":        game.ClassNames(),
+					},
+					"seed": map[string]interface{}{
+						"type":        []string{"integer", "string"},
+						"description": "Dungeon seed to play a specific run (random if omitted)",
+					},
+					"daily": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Play today's daily challenge dungeon (seed from the UTC date, overrides seed)",
+					},
+					"difficulty": map[string]interface{}{
+						"type":        "string",
+						"description": "Dungeon size preset: easy (4x4), normal (5x5) or hard (7x7)",
+						"enum":        []string{"easy", "normal", "hard"},
+					},
+					"width": map[string]interface{}{
+						"type":        "integer",
+						"description": "Dungeon width in rooms, overriding the preset (3-12)",
+						"minimum":     generator.MinGridSize,
+						"maximum":     generator.MaxGridSize,
+					},
+					"height": map[string]interface{}{
+						"type":        "integer",
+						"description": "Dungeon height in rooms, overriding the preset (3-12)",
+						"minimum":     generator.MinGridSize,
+						"maximum":     generator.MaxGridSize,
+					},
+					"final_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of dungeon levels; the exit of the deepest one wins the game (default 3, max 10)",
+						"minimum":     1,
+						"maximum":     game.MaxFinalDepth,
+					},
 				},
 				"required": []string{"character_name"},
 			},
@@ -482,6 +678,14 @@ func (s *Server) ListTools() []Tool {
 				"required": []string{"direction"},
 			},
 		},
+		{
+			Name:        "descend",
+			Description: "Take the stairs in the current level's exit room down to the next level",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 		{
 			Name:        "attack",
 			Description: "Attack a monster in the current room",
@@ -496,6 +700,29 @@ func (s *Server) ListTools() []Tool {
 				"required": []string{"target_id"},
 			},
 		},
+		{
+			Name:        "flee",
+			Description: "Try to escape combat to the room you came from (Dexterity check against each monster; failure gives them a free attack)",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "level_up",
+			Description: "Spend a stat point earned by leveling up to raise strength or dexterity",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"stat": map[string]interface{}{
+						"type":        "string",
+						"description": "Stat to raise",
+						"enum":        []string{"strength", "dexterity"},
+					},
+				},
+				"required": []string{"stat"},
+			},
+		},
 		{
 			Name:        "take",
 			Description: "Pick up an item from the current room",
@@ -520,6 +747,43 @@ func (s *Server) ListTools() []Tool {
 						"type":        "string",
 						"description": "ID of the item to use",
 					},
+					"target_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Monster to throw a harmful item at (optional if only one monster is present)",
+					},
+				},
+				"required": []string{"item_id"},
+			},
+		},
+		{
+			Name:        "cast",
+			Description: "Cast one of your class's abilities, spending mana. In combat the monsters still strike back.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"ability": map[string]interface{}{
+						"type":        "string",
+						"description": "Ability to cast (see 'stats' for the ones you know)",
+						"enum":        game.AbilityNames(),
+					},
+					"target_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Monster to cast a damaging ability at (optional if only one monster is present)",
+					},
+				},
+				"required": []string{"ability"},
+			},
+		},
+		{
+			Name:        "drop",
+			Description: "Drop an item from your inventory on the floor of the current room",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"item_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the item to drop",
+					},
 				},
 				"required": []string{"item_id"},
 			},
@@ -562,114 +826,578 @@ func (s *Server) ListTools() []Tool {
 				"required": []string{"item_id"},
 			},
 		},
+		{
+			Name:        "unequip",
+			Description: "Take off an equipped weapon or armor, keeping it in your inventory",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"item_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the item to unequip",
+					},
+				},
+				"required": []string{"item_id"},
+			},
+		},
+		{
+			Name:        "shop",
+			Description: "Browse the wares of the merchant in the current room, with prices",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "buy",
+			Description: "Buy an item from the merchant in the current room",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"item_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the item to buy",
+					},
+				},
+				"required": []string{"item_id"},
+			},
+		},
+		{
+			Name:        "sell",
+			Description: "Sell an item from your inventory to the merchant in the current room",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"item_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the item to sell",
+					},
+				},
+				"required": []string{"item_id"},
+			},
+		},
+		{
+			Name:        "unlock",
+			Description: "Unlock a locked door with the matching key from your inventory",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of the locked door",
+						"enum":        []string{"north", "south", "east", "west"},
+					},
+				},
+				"required": []string{"direction"},
+			},
+		},
+		{
+			Name:        "search",
+			Description: "Search the current room, or the room through an exit, for hidden traps (Dexterity check)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Exit to search through (north/south/east/west); omit to search the current room",
+						"enum":        []string{"north", "south", "east", "west"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "disarm",
+			Description: "Attempt to disarm a discovered trap in the current room or through an exit (Dexterity check)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"trap_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the trap to disarm",
+					},
+				},
+				"required": []string{"trap_id"},
+			},
+		},
+		{
+			Name:        "export_replay",
+			Description: "Export the current game as a replay file (seed plus action log) for reproducing bugs",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "replay",
+			Description: "Rebuild a game from a replay file and verify it reaches the recorded state",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"replay": map[string]interface{}{
+						"type":        "string",
+						"description": "Replay file contents as produced by export_replay",
+					},
+				},
+				"required": []string{"replay"},
+			},
+		},
 	}
 }
 
-// CallTool executes an MCP tool
-func (s *Server) CallTool(name string, arguments map[string]interface{}) (*ToolResult, error) {
-	switch name {
-	case "new_game":
-		charName, ok := arguments["character_name"].(string)
-		if !ok || charName == "" {
-			charName = "Hero"
-		}
-		return s.handleNewGame(charName)
-	case "look":
-		return s.handleLook()
-	case "move":
-		direction, ok := arguments["direction"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid direction")
-		}
-		return s.handleMove(direction)
-	case "attack":
-		targetID, ok := arguments["target_id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid target_id")
-		}
-		return s.handleAttack(targetID)
-	case "take":
-		itemID, ok := arguments["item_id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid item_id")
-		}
-		return s.handleTake(itemID)
-	case "use":
-		itemID, ok := arguments["item_id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid item_id")
-		}
-		return s.handleUse(itemID)
-	case "inventory":
-		return s.handleInventory()
-	case "stats":
-		return s.handleStats()
-	case "map":
-		return s.handleMap()
-	case "equip":
-		itemID, ok := arguments["item_id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid item_id")
-		}
-		return s.handleEquip(itemID)
-	default:
-		return nil, fmt.Errorf("unknown tool: %s", name)
+// CallTool executes an MCP tool against the game owned by sessionID.
+// If sessionID is empty, the "session_id" argument is used instead.
+// new_game and replay create the session (generating an ID if none was
+// given); every other tool requires an existing session.
+func (s *Server) CallTool(sessionID string, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	if sessionID == "" {
+		sessionID, _ = arguments["session_id"].(string)
 	}
-}
 
-// handleNewGame starts a new game
-func (s *Server) handleNewGame(characterName string) (*ToolResult, error) {
-	// Reset game state
-	s.state = game.NewGameState()
-
-	// Create character
-	character := game.NewCharacter(characterName)
-	s.state.Character = character
+	var sess *Session
+	if sessionStartingTools[name] {
+		if sessionID == "" {
+			sessionID = NewSessionID()
+		}
+		sess = s.sessions.GetOrCreate(sessionID)
+	} else if existing := s.resumeSession(sessionID); existing != nil {
+		sess = existing
+	} else {
+		// Unknown session: run against an empty, unregistered game so the
+		// handlers report "no game in progress" as usual
+		sess = newSession(sessionID)
+	}
+	sess.touch()
+
+	// Serialize mutating tools per game; read-only tools may run concurrently.
+	// The lock is held through saving and publishing so both see this call's
+	// state and not a later one's.
+	if readOnlyTools[name] {
+		sess.state.RLock()
+		defer sess.state.RUnlock()
+	} else {
+		sess.state.Lock()
+		defer sess.state.Unlock()
+	}
 
-	// Generate dungeon with seeded randomness
-	seed := time.Now().UnixNano()
-	gen := generator.NewDungeonGenerator(seed)
-	game.SetCombatSeed(seed) // Use same seed for reproducible combat
-	dungeon, rooms, connections, err := gen.GenerateDungeon(1) // Depth 1 for MVP
+	result, err := sess.callTool(name, arguments)
 	if err != nil {
-		return &ToolResult{
-			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Failed to generate dungeon: %v", err)}},
-			IsError: true,
-		}, nil
+		return nil, err
 	}
 
-	s.state.Dungeon = dungeon
+	if s.sessions.Has(sess.ID) {
+		result.SessionID = sess.ID
+		if !readOnlyTools[name] {
+			s.saveSession(sess)
+			if result.GameState != nil {
+				s.events.Publish(sess.ID, newGameStateNotification(name, result))
+			}
+		}
+	}
+	return result, nil
+}
 
-	// Add rooms and connections
-	for _, room := range rooms {
-		s.state.AddRoom(room)
+// resumeSession returns the in-memory session for sessionID, loading it from
+// the store if the server has restarted since it was last used
+func (s *Server) resumeSession(sessionID string) *Session {
+	if sessionID == "" {
+		return nil
 	}
-	for _, conn := range connections {
-		s.state.AddConnection(conn)
+	if sess, ok := s.sessions.Get(sessionID); ok {
+		return sess
 	}
-
-	// Find entrance and set character's starting position
-	for _, room := range rooms {
-		if room.IsEntrance {
-			character.CurrentRoomID = room.ID
-			s.state.MarkRoomVisited(room.ID)
-			break
-		}
+	if s.store == nil {
+		return nil
 	}
 
-	// Populate rooms with monsters and items
-	for _, room := range rooms {
-		difficulty := room.X + room.Y // Manhattan distance from entrance
-		monsters, items, traps := gen.PopulateRoom(room, difficulty)
-		for _, m := range monsters {
-			s.state.AddMonster(m)
+	state, err := s.store.LoadGame(sessionID)
+	if err != nil {
+		log.Printf("Error loading game for session %s: %v", sessionID, err)
+		return nil
+	}
+	if state == nil {
+		return nil
+	}
+	return s.sessions.Add(&Session{ID: sessionID, state: state})
+}
+
+// saveSession persists a session's game state, logging (not failing) on error
+func (s *Server) saveSession(sess *Session) {
+	if s.store == nil || !sess.state.IsInitialized() {
+		return
+	}
+	if err := s.store.SaveGame(sess.ID, sess.state); err != nil {
+		log.Printf("Error saving game for session %s: %v", sess.ID, err)
+	}
+}
+
+// callTool runs a tool and records it in the game's action log if it was accepted
+func (s *Session) callTool(name string, arguments map[string]interface{}) (*ToolResult, error) {
+	// Only mutating tools hold the write lock, so only they track acceptance
+	logged := !readOnlyTools[name] && !unloggedTools[name]
+	if logged {
+		s.accepted = false
+	}
+
+	turn := s.state.TurnNumber
+	result, err := s.dispatchTool(name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	// Only an action that took a turn ends one: rejected actions leave the
+	// turn counter alone. A replay ends turns in the calls it replays, not
+	// afterwards.
+	if !unloggedTools[name] && s.state.IsInitialized() && s.state.TurnNumber > turn && !result.IsError && !s.state.GameOver {
+		s.endTurn(result)
+	}
+	// Rejected actions change nothing, so replays don't need them
+	if logged && s.accepted && s.state.IsInitialized() {
+		delete(arguments, "session_id")
+		s.state.RecordAction(name, arguments)
+	}
+	return result, nil
+}
+
+// endTurn runs everything that happens after an accepted player turn: status
+// effects tick, mana recovers, then the world tick lets monsters wander. It is
+// never run for rejected actions, which must not move monsters or draw dice.
+// What happened is added to the turn's result.
+func (s *Session) endTurn(result *ToolResult) {
+	var sb strings.Builder
+	s.tickEffects(&sb)
+	if !s.state.GameOver {
+		s.state.Character.RestoreMana(game.ManaRegenPerTurn)
+		s.wanderMonsters(&sb)
+	}
+
+	if sb.Len() == 0 || result == nil {
+		return
+	}
+	if len(result.Content) > 0 {
+		result.Content[0].Text = strings.TrimRight(result.Content[0].Text, "\n") + "\n\n" + sb.String()
+	}
+	result.GameState = s.buildGameStateSnapshot()
+}
+
+// tickEffects runs the status effects at the end of a turn: first on the
+// monsters in the character's room, then on the character
+func (s *Session) tickEffects(sb *strings.Builder) {
+	char := s.state.Character
+	// Decided before the monsters tick: a stun already spent on this turn's
+	// fight must not also wear off because poison finished the last monster
+	inCombat := s.state.HasMonstersInRoom(char.CurrentRoomID)
+
+	for _, monster := range s.state.GetRoomMonsters(char.CurrentRoomID) {
+		tick := monster.TickEffects()
+		writeEffectTick(sb, "The "+monster.Name, tick)
+		if monster.IsAlive {
+			continue
 		}
-		for _, item := range items {
-			s.state.AddItem(item)
+		drops := s.state.KillMonster(monster.ID)
+		s.state.RecordMonsterDefeated(monster.ID)
+		sb.WriteString(fmt.Sprintf("✨ The %s succumbs to its wounds!\n", monster.Name))
+		sb.WriteString(s.awardXP(monster))
+		for _, item := range drops {
+			sb.WriteString(fmt.Sprintf("💰 The %s dropped a %s! [ID: %s]\n", monster.Name, item.Name, item.ID))
+		}
+		if !s.state.HasMonstersInRoom(char.CurrentRoomID) {
+			sb.WriteString("The room is now clear. You may proceed.\n")
+		}
+	}
+
+	tick := char.TickEffects(inCombat)
+	writeEffectTick(sb, "You", tick)
+	if !char.IsAlive {
+		s.state.KillCharacter()
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:    "death",
+			Subtype: "player_died",
+		})
+		sb.WriteString("\n💀 YOU HAVE DIED 💀\n\n" + s.scoreSummary() + "Use 'new_game' to try again.")
+	}
+}
+
+// wanderMonsters runs the world tick and reports monsters that wander into
+// the character's room
+func (s *Session) wanderMonsters(sb *strings.Builder) {
+	roomID := s.state.Character.CurrentRoomID
+	for _, move := range s.state.WanderMonsters() {
+		if move.ToRoomID != roomID {
+			continue
+		}
+		monster := s.state.Monsters[move.MonsterID]
+		s.state.RecordMonsterArrived(monster.ID)
+
+		from := "the shadows"
+		for direction, neighbourID := range s.state.GetRoomExits(roomID) {
+			if neighbourID == move.FromRoomID {
+				from = "the " + direction
+			}
+		}
+		sb.WriteString(fmt.Sprintf("⚔️  A %s wanders in from %s! (HP: %d/%d) [ID: %s]\n",
+			monster.Name, from, monster.HP, monster.MaxHP, monster.ID))
+	}
+}
+
+// writeEffectTick describes one turn of status effects on the character
+// (subject "You") or a monster (subject "The Rat")
+func writeEffectTick(sb *strings.Builder, subject string, tick game.EffectTick) {
+	takes, recovers, possessive := "takes", "recovers", subject+"'s"
+	if subject == "You" {
+		takes, recovers, possessive = "take", "recover", "Your"
+	}
+	if tick.Healing > 0 {
+		sb.WriteString(fmt.Sprintf("%s %s %d HP from regeneration.\n", subject, recovers, tick.Healing))
+	}
+	if tick.Damage > 0 {
+		sb.WriteString(fmt.Sprintf("%s %s %d damage from %s.\n", subject, takes, tick.Damage, strings.Join(tick.Causes, " and ")))
+	}
+	for _, effect := range tick.Expired {
+		sb.WriteString(fmt.Sprintf("%s %s wears off.\n", possessive, effect))
+	}
+}
+
+// dispatchTool dispatches a tool call to the matching handler
+func (s *Session) dispatchTool(name string, arguments map[string]interface{}) (*ToolResult, error) {
+	switch name {
+	case "new_game":
+		opts := parseNewGameOptions(arguments)
+		return s.handleNewGame(opts, opts.resolveSeed(time.Now()), game.NewIDPrefix())
+	case "look":
+		return s.handleLook()
+	case "move":
+		direction, ok := arguments["direction"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid direction")
+		}
+		return s.handleMove(direction)
+	case "descend":
+		return s.handleDescend()
+	case "attack":
+		targetID, ok := arguments["target_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid target_id")
+		}
+		return s.handleAttack(targetID)
+	case "flee":
+		return s.handleFlee()
+	case "level_up":
+		stat, ok := arguments["stat"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid stat")
+		}
+		return s.handleLevelUp(stat)
+	case "take":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleTake(itemID)
+	case "use":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		targetID, _ := arguments["target_id"].(string)
+		return s.handleUse(itemID, targetID)
+	case "cast":
+		ability, ok := arguments["ability"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid ability")
+		}
+		targetID, _ := arguments["target_id"].(string)
+		return s.handleCast(ability, targetID)
+	case "drop":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleDrop(itemID)
+	case "inventory":
+		return s.handleInventory()
+	case "stats":
+		return s.handleStats()
+	case "map":
+		return s.handleMap()
+	case "equip":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleEquip(itemID)
+	case "unequip":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleUnequip(itemID)
+	case "shop":
+		return s.handleShop()
+	case "buy":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleBuy(itemID)
+	case "sell":
+		itemID, ok := arguments["item_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid item_id")
+		}
+		return s.handleSell(itemID)
+	case "unlock":
+		direction, ok := arguments["direction"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid direction")
 		}
-		for _, trap := range traps {
-			s.state.AddTrap(trap)
+		return s.handleUnlock(direction)
+	case "search":
+		direction, _ := arguments["direction"].(string)
+		return s.handleSearch(direction)
+	case "disarm":
+		trapID, ok := arguments["trap_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid trap_id")
+		}
+		return s.handleDisarm(trapID)
+	case "export_replay":
+		return s.handleExportReplay()
+	case "replay":
+		data, err := replayArgument(arguments["replay"])
+		if err != nil {
+			return nil, err
 		}
+		return s.handleReplay(data)
+	default:
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+}
+
+// newGameOptions are the settings accepted by new_game
+type newGameOptions struct {
+	CharacterName string
+	Seed          int64
+	HasSeed       bool // Seed was chosen by the player
+	Daily         bool // Derive the seed from today's UTC date
+	FinalDepth    int  // Levels to descend through before the exit escapes the dungeon
+	Width         int  // Grid columns on every level
+	Height        int  // Grid rows on every level
+	Class         string
+}
+
+// parseNewGameOptions reads new_game arguments, applying defaults
+func parseNewGameOptions(arguments map[string]interface{}) newGameOptions {
+	opts := newGameOptions{
+		CharacterName: "Hero",
+		Class:         game.DefaultClass,
+		FinalDepth:    game.DefaultFinalDepth,
+		Width:         generator.DefaultWidth,
+		Height:        generator.DefaultHeight,
+	}
+	if name, ok := arguments["character_name"].(string); ok && name != "" {
+		opts.CharacterName = name
+	}
+	if class, ok := arguments["class"].(string); ok && class != "" {
+		opts.Class = strings.ToLower(class)
+	}
+
+	// JSON numbers arrive as float64; large seeds can be passed as strings
+	// to keep every digit
+	switch seed := arguments["seed"].(type) {
+	case float64:
+		opts.Seed, opts.HasSeed = int64(seed), true
+	case string:
+		if n, err := strconv.ParseInt(seed, 10, 64); err == nil {
+			opts.Seed, opts.HasSeed = n, true
+		}
+	}
+
+	if daily, ok := arguments["daily"].(bool); ok {
+		opts.Daily = daily
+	}
+
+	if depth, ok := arguments["final_depth"].(float64); ok {
+		opts.FinalDepth = int(depth)
+		if opts.FinalDepth < 1 {
+			opts.FinalDepth = 1
+		}
+		if opts.FinalDepth > game.MaxFinalDepth {
+			opts.FinalDepth = game.MaxFinalDepth
+		}
+	}
+
+	// A difficulty preset picks the size; explicit dimensions override it
+	if difficulty, ok := arguments["difficulty"].(string); ok {
+		if preset, ok := generator.SizePresets[difficulty]; ok {
+			opts.Width, opts.Height = preset.Width, preset.Height
+		}
+	}
+	if width, ok := arguments["width"].(float64); ok {
+		opts.Width = generator.ClampGridSize(int(width))
+	}
+	if height, ok := arguments["height"].(float64); ok {
+		opts.Height = generator.ClampGridSize(int(height))
+	}
+	return opts
+}
+
+// maxClockSeed keeps seeds drawn from the clock within the integers a
+// JavaScript number holds exactly (2^53 - 1), so clients can read the
+// reported seed back and pass it to new_game unchanged
+const maxClockSeed = 1<<53 - 1
+
+// resolveSeed picks the seed for a new game: the daily seed if requested,
+// then the player's seed, otherwise the clock
+func (o newGameOptions) resolveSeed(now time.Time) int64 {
+	switch {
+	case o.Daily:
+		return DailySeed(now)
+	case o.HasSeed:
+		return o.Seed
+	default:
+		return now.UnixNano() & maxClockSeed
+	}
+}
+
+// DailySeed returns the shared seed for the UTC day containing t, written as
+// the date digits (2024-03-15 -> 20240315) so players can recognise it
+func DailySeed(t time.Time) int64 {
+	y, m, d := t.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// handleNewGame starts a new game. The seed drives generation and every
+// later dice roll, and idPrefix is shared by all IDs in the game, so the same
+// seed, prefix and actions always reproduce the same game.
+func (s *Session) handleNewGame(opts newGameOptions, seed int64, idPrefix string) (*ToolResult, error) {
+	// Validate the class before discarding the current game
+	class, err := game.GetClass(opts.Class)
+	if err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
+
+	// Reset game state in place; the caller holds its write lock
+	s.state.Reset()
+	s.state.Dice = game.NewDice(seed) // Use same seed for reproducible combat
+	s.state.IDPrefix = idPrefix
+
+	// Create character
+	character := game.NewCharacter(opts.CharacterName)
+	character.ID = s.state.NewID()
+	character.ApplyClass(opts.Class, class)
+	s.state.Character = character
+	startingItems := s.state.GiveStartingItems(class)
+
+	s.state.FinalDepth = opts.FinalDepth
+
+	// Generate the first level with seeded randomness
+	if err := s.buildLevel(seed, 1, opts.Width, opts.Height); err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Failed to generate dungeon: %v", err)}},
+			IsError: true,
+		}, nil
 	}
 
 	// Initialize turn context with game start event
@@ -682,14 +1410,175 @@ func (s *Server) handleNewGame(characterName string) (*ToolResult, error) {
 	// Build response
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("=== NEW GAME STARTED ===\n\n"))
-	sb.WriteString(fmt.Sprintf("Welcome, %s!\n\n", character.Name))
+	sb.WriteString(fmt.Sprintf("Welcome, %s the %s!\n", character.Name, class.Name))
+	sb.WriteString(fmt.Sprintf("%s\n\n", class.Description))
 	sb.WriteString(fmt.Sprintf("You find yourself at the entrance of a dark dungeon.\n"))
-	sb.WriteString(fmt.Sprintf("Your goal: reach the exit on the other side.\n"))
+	if opts.FinalDepth > 1 {
+		sb.WriteString(fmt.Sprintf("Your goal: descend %d levels and escape through the deepest exit.\n", opts.FinalDepth))
+	} else {
+		sb.WriteString(fmt.Sprintf("Your goal: reach the exit on the other side.\n"))
+	}
 	sb.WriteString(fmt.Sprintf("Beware of the monsters that lurk within!\n\n"))
-	sb.WriteString(fmt.Sprintf("Stats: HP %d/%d | STR %d | DEX %d\n\n",
+	sb.WriteString(fmt.Sprintf("Stats: HP %d/%d | STR %d | DEX %d\n",
 		character.HP, character.MaxHP, character.Strength, character.Dexterity))
+	if class.Passive.Name != "" {
+		sb.WriteString(fmt.Sprintf("Passive: %s (%s)\n", class.Passive.Name, class.Passive.Description))
+	}
+	if len(startingItems) > 0 {
+		sb.WriteString("Starting gear:\n")
+		for _, item := range startingItems {
+			equipped := ""
+			if item.IsEquipped {
+				equipped = " (equipped)"
+			}
+			sb.WriteString(fmt.Sprintf("  - %s%s\n", item.Name, equipped))
+		}
+	}
+	sb.WriteString("\n")
+	if opts.Daily {
+		sb.WriteString(fmt.Sprintf("Daily challenge seed: %d\n\n", seed))
+	} else {
+		sb.WriteString(fmt.Sprintf("Seed: %d\n\n", seed))
+	}
 	sb.WriteString("Use 'look' to see your surroundings.")
 
+	s.acceptAction()
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// generatedLevel is a level built by generateLevel but not yet entered
+type generatedLevel struct {
+	dungeon     *game.Dungeon
+	rooms       []*game.Room
+	connections []*game.RoomConnection
+	entrance    *game.Room
+	monsters    []*game.Monster
+	items       []*game.Item // Floor items and the keys to locked doors
+	traps       []*game.Trap
+	merchant    *game.Merchant
+}
+
+// buildLevel generates the level at depth for the run with the given seed and
+// grid size, replaces the current level with it, puts the character at its
+// entrance and fills it with monsters, items, traps, locked doors and perhaps
+// a merchant
+func (s *Session) buildLevel(seed int64, depth, width, height int) error {
+	level, err := s.generateLevel(seed, depth, width, height)
+	if err != nil {
+		return err
+	}
+	s.enterLevel(level)
+	return nil
+}
+
+// generateLevel builds the level at depth without touching the game, so a
+// failure leaves the current level as it was
+func (s *Session) generateLevel(seed int64, depth, width, height int) (*generatedLevel, error) {
+	gen := generator.NewLevelGenerator(seed, depth).WithIDPrefix(s.state.IDPrefix).WithSize(width, height)
+	dungeon, rooms, connections, err := gen.GenerateDungeon(depth)
+	if err != nil {
+		return nil, err
+	}
+	if dungeon == nil {
+		return nil, fmt.Errorf("level %d has no dungeon", depth)
+	}
+
+	level := &generatedLevel{dungeon: dungeon, rooms: rooms, connections: connections}
+	for _, room := range rooms {
+		if room.IsEntrance {
+			level.entrance = room
+			break
+		}
+	}
+	if level.entrance == nil {
+		return nil, fmt.Errorf("level %d has no entrance", depth)
+	}
+
+	// Populate rooms with monsters and items, harder the deeper the level
+	for _, room := range rooms {
+		monsters, items, traps := gen.PopulateRoom(room, generator.LevelDifficulty(room, level.entrance, depth))
+		level.monsters = append(level.monsters, monsters...)
+		level.items = append(level.items, items...)
+		level.traps = append(level.traps, traps...)
+	}
+
+	// Lock some doors and hide their keys
+	level.items = append(level.items, gen.PlaceLocks(rooms, connections)...)
+
+	level.merchant = gen.PlaceMerchant(rooms, depth)
+	return level, nil
+}
+
+// enterLevel replaces the current level with a generated one and puts the
+// character at its entrance
+func (s *Session) enterLevel(level *generatedLevel) {
+	// EnterLevel only fails without a dungeon, which generateLevel rules out
+	_ = s.state.EnterLevel(level.dungeon, level.rooms, level.connections)
+
+	s.state.Character.CurrentRoomID = level.entrance.ID
+	s.state.MarkRoomVisited(level.entrance.ID)
+
+	for _, m := range level.monsters {
+		s.state.AddMonster(m)
+	}
+	for _, item := range level.items {
+		s.state.AddItem(item)
+	}
+	for _, trap := range level.traps {
+		s.state.AddTrap(trap)
+	}
+	if level.merchant != nil {
+		s.state.AddMerchant(level.merchant)
+	}
+}
+
+// handleDescend takes the stairs in the current room down to the next level
+func (s *Session) handleDescend() (*ToolResult, error) {
+	if errResult := s.requireActiveGame(); errResult != nil {
+		return errResult, nil
+	}
+
+	if !s.state.CanDescend() {
+		message := "There are no stairs here. Find the exit of this level first."
+		if s.state.IsFinalDepth() {
+			message = "This is the deepest level - there is nowhere further down. Find the exit to escape!"
+		}
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: message}},
+		}, nil
+	}
+
+	// Generate the level before taking the turn, so a failure costs nothing
+	depth := s.state.Dungeon.Depth + 1
+	dungeon := s.state.Dungeon
+	level, err := s.generateLevel(dungeon.Seed, depth, dungeon.Width, dungeon.Height)
+	if err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Failed to generate level %d: %v", depth, err)}},
+			IsError: true,
+		}, nil
+	}
+
+	s.beginMovementTurn()
+	s.enterLevel(level)
+
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:    "movement",
+		Subtype: "level_descend",
+	})
+
+	room := s.state.GetCurrentRoom()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("You descend the stairs to depth %d of %d...\n\n", depth, s.state.FinalDepth))
+	sb.WriteString(fmt.Sprintf("=== %s ===\n\n", room.Name))
+	sb.WriteString(fmt.Sprintf("%s\n", room.Description))
+	if s.state.IsFinalDepth() {
+		sb.WriteString("\nThis is the deepest level. Its exit leads out of the dungeon.\n")
+	}
+
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
 		GameState: s.buildGameStateSnapshot(),
@@ -697,7 +1586,7 @@ func (s *Server) handleNewGame(characterName string) (*ToolResult, error) {
 }
 
 // handleLook shows the current room
-func (s *Server) handleLook() (*ToolResult, error) {
+func (s *Session) handleLook() (*ToolResult, error) {
 	if errResult := s.requireActiveGame(); errResult != nil {
 		return errResult, nil
 	}
@@ -727,15 +1616,20 @@ func (s *Server) handleLook() (*ToolResult, error) {
 		sb.WriteString("[This is the dungeon entrance]\n\n")
 	}
 	if room.IsExit {
-		sb.WriteString("[This is the dungeon exit - reach here to win!]\n\n")
+		if s.state.IsFinalDepth() {
+			sb.WriteString("[This is the dungeon exit - reach here to win!]\n\n")
+		} else {
+			sb.WriteString("[Stairs lead down to the next level - use 'descend' to go deeper]\n\n")
+		}
 	}
 
 	// Exits
-	exits := s.state.GetRoomExits(room.ID)
-	if len(exits) > 0 {
-		exitDirs := make([]string, 0, len(exits))
-		for dir := range exits {
-			exitDirs = append(exitDirs, dir)
+	exitDirs := s.state.GetExitDirections(room.ID)
+	if len(exitDirs) > 0 {
+		for i, dir := range exitDirs {
+			if conn := s.state.GetExitConnection(room.ID, dir); conn != nil && conn.IsLocked {
+				exitDirs[i] = dir + " (locked)"
+			}
 		}
 		sb.WriteString(fmt.Sprintf("Exits: %s\n\n", strings.Join(exitDirs, ", ")))
 	} else {
@@ -748,6 +1642,9 @@ func (s *Server) handleLook() (*ToolResult, error) {
 		sb.WriteString("Monsters:\n")
 		for _, m := range monsters {
 			sb.WriteString(fmt.Sprintf("  - %s (HP: %d/%d) [ID: %s]\n", m.Name, m.HP, m.MaxHP, m.ID))
+			if len(m.Effects) > 0 {
+				sb.WriteString(fmt.Sprintf("    %s\n", game.DescribeEffects(m.Effects)))
+			}
 			sb.WriteString(fmt.Sprintf("    %s\n", m.Description))
 		}
 		sb.WriteString("\n")
@@ -758,12 +1655,28 @@ func (s *Server) handleLook() (*ToolResult, error) {
 	if len(items) > 0 {
 		sb.WriteString("Items:\n")
 		for _, item := range items {
-			sb.WriteString(fmt.Sprintf("  - %s [ID: %s]\n", item.Name, item.ID))
+			sb.WriteString(fmt.Sprintf("  - %s%s [ID: %s]\n", item.Name, rarityTag(item), item.ID))
 			sb.WriteString(fmt.Sprintf("    %s\n", item.Description))
 		}
 		sb.WriteString("\n")
 	}
 
+	// Traps the player has found
+	traps := s.state.GetDiscoveredTraps(room.ID)
+	if len(traps) > 0 {
+		sb.WriteString("Traps:\n")
+		for _, t := range traps {
+			sb.WriteString(fmt.Sprintf("  - %s (DC %d) [ID: %s]\n", t.Name, t.Difficulty, t.ID))
+			sb.WriteString(fmt.Sprintf("    %s\n", t.Description))
+		}
+		sb.WriteString("\n")
+	}
+
+	// A merchant's wares are listed by 'shop'
+	if merchant := s.state.GetRoomMerchant(room.ID); merchant != nil {
+		sb.WriteString(fmt.Sprintf("A %s has set up shop here. Use 'shop' to see their wares.\n\n", merchant.Name))
+	}
+
 	// Warning if monsters block exit
 	if len(monsters) > 0 {
 		sb.WriteString("⚔️  Monsters block your path! Defeat them to proceed.\n")
@@ -776,13 +1689,25 @@ func (s *Server) handleLook() (*ToolResult, error) {
 }
 
 // handleMove moves the character
-func (s *Server) handleMove(direction string) (*ToolResult, error) {
+func (s *Session) handleMove(direction string) (*ToolResult, error) {
 	if errResult := s.requireActiveGame(); errResult != nil {
 		return errResult, nil
 	}
 
+	if err := s.state.CheckMove(direction); err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
 	s.beginMovementTurn()
 
+	// A carried key opens a locked door on the way through
+	var keyUsed *game.Item
+	if conn := s.state.GetExitConnection(s.state.Character.CurrentRoomID, direction); conn != nil && conn.IsLocked {
+		keyUsed = s.state.Items[conn.KeyID]
+	}
+
 	err := s.state.MoveCharacter(direction)
 	if err != nil {
 		return &ToolResult{
@@ -802,7 +1727,7 @@ func (s *Server) handleMove(direction string) (*ToolResult, error) {
 			Subtype: "dungeon_escaped",
 		})
 		return &ToolResult{
-			Content:   []ContentBlock{{Type: "text", Text: "You step through the exit and escape the dungeon!\n\n🏆 VICTORY! 🏆\n\nCongratulations, brave adventurer! Use 'new_game' to play again."}},
+			Content:   []ContentBlock{{Type: "text", Text: "You step through the exit and escape the dungeon!\n\n🏆 VICTORY! 🏆\n\n" + s.scoreSummary() + "Congratulations, brave adventurer! Use 'new_game' to play again."}},
 			GameState: s.buildGameStateSnapshot(),
 		}, nil
 	}
@@ -810,10 +1735,61 @@ func (s *Server) handleMove(direction string) (*ToolResult, error) {
 	// Show the new room
 	newRoom := s.state.GetCurrentRoom()
 	var sb strings.Builder
+	if keyUsed != nil {
+		sb.WriteString(fmt.Sprintf("You unlock the door with the %s.\n", keyUsed.Name))
+	}
 	sb.WriteString(fmt.Sprintf("You move %s...\n\n", direction))
 	sb.WriteString(fmt.Sprintf("=== %s ===\n\n", newRoom.Name))
 	sb.WriteString(fmt.Sprintf("%s\n", newRoom.Description))
 
+	s.writeArrival(&sb, newRoom)
+	if s.state.CanDescend() && !s.state.GameOver {
+		sb.WriteString("\nStairs lead down into the darkness. Use 'descend' to go deeper.\n")
+	}
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// writeArrival reports what happens as the character enters a room: traps
+// that go off (or are avoided), the monsters waiting there and any merchant
+func (s *Session) writeArrival(sb *strings.Builder, newRoom *game.Room) {
+	// Entering a room springs its armed traps unless the player saves
+	for _, tr := range s.state.SpringTraps() {
+		if tr.Success {
+			sb.WriteString(fmt.Sprintf("\n⚠️  You spot a %s just in time and avoid it! (DEX save %d vs DC %d) [ID: %s]\n",
+				tr.TrapName, tr.Roll, tr.DC, tr.TrapID))
+			s.state.SetLastEvent(&game.EventInfo{
+				Type:     "movement",
+				Subtype:  "trap_avoided",
+				Entities: []string{tr.TrapID},
+				Trap:     tr,
+			})
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("\n💥 You trigger a %s and take %d damage! (DEX save %d vs DC %d)\n",
+			tr.TrapName, tr.Damage, tr.Roll, tr.DC))
+		if s.state.GameOver {
+			s.state.SetLastEvent(&game.EventInfo{
+				Type:     "death",
+				Subtype:  "player_died",
+				Entities: []string{tr.TrapID},
+				Trap:     tr,
+			})
+			sb.WriteString("\n💀 YOU HAVE DIED 💀\n\n" + s.scoreSummary() + "Use 'new_game' to try again.")
+			return
+		}
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:     "movement",
+			Subtype:  "trap_triggered",
+			Entities: []string{tr.TrapID},
+			Trap:     tr,
+		})
+	}
+
 	// Check for monsters
 	monsters := s.state.GetRoomMonsters(newRoom.ID)
 	if len(monsters) > 0 {
@@ -823,14 +1799,170 @@ func (s *Server) handleMove(direction string) (*ToolResult, error) {
 		}
 	}
 
+	if merchant := s.state.GetRoomMerchant(newRoom.ID); merchant != nil {
+		sb.WriteString(fmt.Sprintf("\n🛒 A %s has set up shop here. Use 'shop' to see their wares.\n", merchant.Name))
+	}
+}
+
+// handleUnlock unlocks a door with a carried key
+func (s *Session) handleUnlock(direction string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	if err := s.state.CheckUnlock(direction); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+
+	key, err := s.state.UnlockDoor(direction)
+	if err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:     "interaction",
+		Subtype:  "door_unlocked",
+		Entities: []string{key.ID},
+	})
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: fmt.Sprintf("You turn the %s in the lock. The door to the %s swings open.", key.Name, direction)}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleSearch searches the current room, or the room through an exit, for traps
+func (s *Session) handleSearch(direction string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	roomID := s.state.Character.CurrentRoomID
+	place := "the room"
+	if direction != "" {
+		exitRoomID, ok := s.state.GetRoomExits(roomID)[direction]
+		if !ok {
+			return &ToolResult{
+				Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("There is no exit to the %s.", direction)}},
+			}, nil
+		}
+		roomID = exitRoomID
+		place = fmt.Sprintf("the passage %s", direction)
+	}
+
+	s.beginTurn()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("You search %s carefully...\n\n", place))
+
+	var found []string
+	var firstFound *game.TrapResult
+	for _, tr := range s.state.SearchForTraps(roomID) {
+		if tr.Success {
+			found = append(found, tr.TrapID)
+			if firstFound == nil {
+				firstFound = tr
+			}
+		}
+	}
+
+	traps := s.state.GetDiscoveredTraps(roomID)
+	if len(traps) == 0 {
+		sb.WriteString("You find no traps.")
+	} else {
+		sb.WriteString("Traps:\n")
+		for _, t := range traps {
+			sb.WriteString(fmt.Sprintf("  - %s (DC %d, %d damage) [ID: %s]\n", t.Name, t.Difficulty, t.Damage, t.ID))
+			sb.WriteString(fmt.Sprintf("    %s\n", t.Description))
+		}
+		sb.WriteString("\nUse 'disarm' to try to disable a trap.")
+	}
+
+	if len(found) > 0 {
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:     "discovery",
+			Subtype:  "trap_found",
+			Entities: found,
+			Trap:     firstFound,
+		})
+	} else {
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:    "interaction",
+			Subtype: "search",
+		})
+	}
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleDisarm attempts to disarm a discovered trap
+func (s *Session) handleDisarm(trapID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	if err := s.state.CheckDisarm(trapID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+
+	tr, err := s.state.DisarmTrap(trapID)
+	if err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	var sb strings.Builder
+	event := &game.EventInfo{
+		Type:     "interaction",
+		Entities: []string{trapID},
+		Trap:     tr,
+	}
+	switch {
+	case tr.Success:
+		event.Subtype = "trap_disarmed"
+		sb.WriteString(fmt.Sprintf("You carefully disarm the %s. (DEX %d vs DC %d)", tr.TrapName, tr.Roll, tr.DC))
+	case tr.Damage > 0:
+		event.Subtype = "trap_triggered"
+		sb.WriteString(fmt.Sprintf("Your hand slips and the %s goes off! You take %d damage. (DEX %d vs DC %d)",
+			tr.TrapName, tr.Damage, tr.Roll, tr.DC))
+	default:
+		event.Subtype = "disarm_failed"
+		sb.WriteString(fmt.Sprintf("You fail to disarm the %s, but it doesn't go off. (DEX %d vs DC %d)", tr.TrapName, tr.Roll, tr.DC))
+	}
+
+	if s.state.GameOver {
+		event.Type = "death"
+		event.Subtype = "player_died"
+		sb.WriteString("\n\n💀 YOU HAVE DIED 💀\n\n" + s.scoreSummary() + "Use 'new_game' to try again.")
+	}
+	s.state.SetLastEvent(event)
+
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
 		GameState: s.buildGameStateSnapshot(),
 	}, nil
 }
 
-// handleAttack attacks a monster
-func (s *Server) handleAttack(targetID string) (*ToolResult, error) {
+// handleAttack attacks a monster. Every other monster in the room attacks too.
+func (s *Session) handleAttack(targetID string) (*ToolResult, error) {
 	if errResult := s.requireActiveGameForAction(); errResult != nil {
 		return errResult, nil
 	}
@@ -857,15 +1989,199 @@ func (s *Server) handleAttack(targetID string) (*ToolResult, error) {
 		}, nil
 	}
 
-	s.beginCombatTurn()
+	s.beginCombatTurn()
+
+	weaponBonus, weaponOnHit, armorBonus := s.equipmentBonuses()
+
+	// Execute combat round
+	monsters := s.state.GetRoomMonsters(s.state.Character.CurrentRoomID)
+	result, enhanced, _ := game.ExecuteCombatTurn(s.state.Dice, s.state.Character, monsters, []*game.Monster{monster},
+		"attack", nil, weaponBonus, weaponOnHit, armorBonus)
+
+	// Store enhanced combat result
+	s.state.SetLastCombatResult(enhanced)
+
+	// Determine event subtype based on outcome
+	eventSubtype := "attack_hit"
+	if attack := enhanced.PlayerAttacks[0]; attack.Stunned {
+		eventSubtype = "stunned"
+		s.state.Character.ConsumeStun()
+	} else if !attack.WasHit {
+		eventSubtype = "attack_miss"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== COMBAT ===\n\n")
+	sb.WriteString(result.Message)
+	sb.WriteString("\n")
+	s.combatOutcome(&sb, result, enhanced, eventSubtype, []string{targetID})
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// combatOutcome settles a combat round: every monster that fell is defeated
+// with its XP and loot, then the character may have died. Otherwise it
+// records a combat event of the given subtype on entities, the monsters the
+// player acted on. Returns the event it recorded.
+func (s *Session) combatOutcome(sb *strings.Builder, result *game.CombatResult, enhanced *game.EnhancedCombatResult, eventSubtype string, entities []string) *game.EventInfo {
+	event := &game.EventInfo{
+		Type:     "combat",
+		Subtype:  eventSubtype,
+		Entities: entities,
+	}
+
+	if len(enhanced.Defeated) > 0 {
+		event.Subtype = "enemy_defeated"
+		event.Entities = make([]string, 0, len(enhanced.Defeated))
+		for _, monsterID := range enhanced.Defeated {
+			monster := s.state.Monsters[monsterID]
+			drops := s.state.KillMonster(monsterID)
+			s.state.RecordMonsterDefeated(monsterID)
+			event.Entities = append(event.Entities, monsterID)
+			for _, item := range drops {
+				event.Entities = append(event.Entities, item.ID)
+			}
+			sb.WriteString(fmt.Sprintf("\n✨ The %s has been defeated!\n", monster.Name))
+			sb.WriteString(s.awardXP(monster))
+			for _, item := range drops {
+				sb.WriteString(fmt.Sprintf("\n💰 The %s dropped a %s! [ID: %s]\n", monster.Name, item.Name, item.ID))
+			}
+		}
+	}
+
+	// Check for player death
+	if result.AttackerDied {
+		s.state.KillCharacter()
+		killer := enhanced.EnemyAttacks[len(enhanced.EnemyAttacks)-1]
+		event = &game.EventInfo{
+			Type:     "death",
+			Subtype:  "player_died",
+			Entities: []string{killer.AttackerID},
+		}
+		sb.WriteString("\n💀 YOU HAVE DIED 💀\n\n" + s.scoreSummary() + "Use 'new_game' to try again.")
+	} else if len(enhanced.Defeated) > 0 && !s.state.HasMonstersInRoom(s.state.Character.CurrentRoomID) {
+		sb.WriteString("\nThe room is now clear. You may proceed.")
+	}
+
+	s.state.SetLastEvent(event)
+	return event
+}
+
+// handleCast casts one of the character's abilities. In combat it takes the
+// place of an attack: a damaging ability hits its targets, and every monster
+// still standing attacks whatever was cast. Out of combat it simply takes
+// effect.
+func (s *Session) handleCast(abilityName, targetID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	ability, targets, err := s.state.PrepareCast(abilityName, targetID)
+	if err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	monsters := s.state.GetRoomMonsters(s.state.Character.CurrentRoomID)
+	if len(monsters) == 0 {
+		s.beginTurn()
+		cast, message := s.state.CastAbility(ability)
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:    "interaction",
+			Subtype: "ability_cast",
+			Cast:    cast,
+		})
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: message}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginCombatTurn()
+
+	_, _, armorBonus := s.equipmentBonuses()
+	result, enhanced, _ := game.ExecuteCombatTurn(s.state.Dice, s.state.Character, monsters, targets, "cast", ability, 0, nil, armorBonus)
+	s.state.SetLastCombatResult(enhanced)
+
+	eventSubtype := "ability_cast"
+	if len(enhanced.PlayerAttacks) > 0 && enhanced.PlayerAttacks[0].Stunned {
+		eventSubtype = "stunned"
+		s.state.Character.ConsumeStun()
+	}
+
+	entities := make([]string, 0, len(targets))
+	for _, target := range targets {
+		entities = append(entities, target.ID)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== COMBAT ===\n\n")
+	sb.WriteString(result.Message)
+	sb.WriteString("\n")
+	event := s.combatOutcome(&sb, result, enhanced, eventSubtype, entities)
+	event.Cast = enhanced.Cast
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// awardXP gives the character the XP for a defeated monster and describes it,
+// including any levels gained
+func (s *Session) awardXP(monster *game.Monster) string {
+	char := s.state.Character
+	xp := game.MonsterXP(monster)
+	levels := char.AwardXP(xp)
+
+	msg := fmt.Sprintf("You gain %d XP. (XP: %d/%d)\n", xp, char.XP, game.XPForLevel(char.Level+1))
+	if levels > 0 {
+		msg += fmt.Sprintf("\n⭐ LEVEL UP! You are now level %d. (HP: %d/%d)\n", char.Level, char.HP, char.MaxHP)
+		msg += fmt.Sprintf("You have %d stat point(s) to spend - use 'level_up' to raise strength or dexterity.\n", char.StatPoints)
+	}
+	return msg
+}
+
+// handleLevelUp spends a stat point on strength or dexterity
+func (s *Session) handleLevelUp(stat string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	char := s.state.Character
+	if err := char.SpendStatPoint(stat); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:    "interaction",
+		Subtype: "level_up",
+	})
+
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Your %s rises by %d. (STR %d | DEX %d, %d stat point(s) left)",
+			stat, game.StatIncrease, char.Strength, char.Dexterity, char.StatPoints)}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
 
-	// Calculate equipment bonuses
-	weaponBonus := 0
-	armorBonus := 0
+// equipmentBonuses returns the damage bonus and on-hit effect of the equipped
+// weapon and the defense bonus of the equipped armor
+func (s *Session) equipmentBonuses() (weaponBonus int, weaponOnHit *game.OnHitEffect, armorBonus int) {
 	if s.state.Character.EquippedWeaponID != nil {
 		weapon := s.state.Items[*s.state.Character.EquippedWeaponID]
 		if weapon != nil {
 			weaponBonus = weapon.Damage
+			weaponOnHit = weapon.OnHit
 		}
 	}
 	if s.state.Character.EquippedArmorID != nil {
@@ -874,54 +2190,100 @@ func (s *Server) handleAttack(targetID string) (*ToolResult, error) {
 			armorBonus = armor.Armor
 		}
 	}
+	return weaponBonus, weaponOnHit, armorBonus
+}
 
-	// Execute combat turn
-	result, enhanced, _ := game.ExecuteCombatTurn(s.state.Character, monster, "attack", weaponBonus, armorBonus)
-
-	// Store enhanced combat result
-	s.state.SetLastCombatResult(enhanced)
+// handleFlee tries to escape combat back to the room the character came from.
+// Every monster in the room gets a pursuit check against the character's
+// Dexterity roll; any that catches them gets a free attack and stops the escape.
+func (s *Session) handleFlee() (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
 
-	// Determine event subtype based on outcome
-	eventSubtype := "attack_hit"
-	if enhanced.PlayerAttack != nil && !enhanced.PlayerAttack.WasHit {
-		eventSubtype = "attack_miss"
+	monsters := s.state.GetRoomMonsters(s.state.Character.CurrentRoomID)
+	if len(monsters) == 0 {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "There is nothing to flee from."}},
+		}, nil
+	}
+	if _, ok := s.state.Rooms[s.state.PreviousRoomID]; !ok {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "There is nowhere to flee to - you must fight!"}},
+		}, nil
 	}
 
+	s.beginCombatTurn()
+
+	_, _, armorBonus := s.equipmentBonuses()
+
 	var sb strings.Builder
-	sb.WriteString("=== COMBAT ===\n\n")
-	sb.WriteString(result.Message)
-	sb.WriteString("\n")
+	sb.WriteString("=== FLEE ===\n\n")
+
+	// Each monster tries to cut off the escape; a stunned character cannot
+	// get away at all
+	stunned := s.state.Character.HasEffect(game.EffectStun)
+	result, enhanced, _ := game.ExecuteCombatTurn(s.state.Dice, s.state.Character, monsters, nil, "flee", nil, 0, nil, armorBonus)
+	s.state.SetLastCombatResult(enhanced)
+	sb.WriteString(result.Message + "\n")
+
+	entities := make([]string, 0, len(monsters))
+	for _, monster := range monsters {
+		entities = append(entities, monster.ID)
+	}
 
-	// Check for player death
 	if result.AttackerDied {
+		killer := enhanced.EnemyAttacks[len(enhanced.EnemyAttacks)-1]
 		s.state.KillCharacter()
 		s.state.SetLastEvent(&game.EventInfo{
 			Type:     "death",
 			Subtype:  "player_died",
-			Entities: []string{targetID},
+			Entities: []string{killer.AttackerID},
 		})
-		sb.WriteString("\n💀 YOU HAVE DIED 💀\n\nUse 'new_game' to try again.")
-	} else if result.DefenderDied {
-		s.state.KillMonster(targetID)
-		s.state.RecordMonsterDefeated(targetID)
-		s.state.SetLastEvent(&game.EventInfo{
-			Type:     "combat",
-			Subtype:  "enemy_defeated",
-			Entities: []string{targetID},
-		})
-		sb.WriteString(fmt.Sprintf("\n✨ The %s has been defeated!\n", monster.Name))
+		sb.WriteString("\n💀 YOU HAVE DIED 💀\n\n" + s.scoreSummary() + "Use 'new_game' to try again.")
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+	if stunned {
+		s.state.Character.ConsumeStun()
+	}
 
-		// Check if room is clear
-		if !s.state.HasMonstersInRoom(s.state.Character.CurrentRoomID) {
-			sb.WriteString("\nThe room is now clear. You may proceed.")
-		}
-	} else {
+	// Every monster that caught the character attacked; the first blocks the way
+	if !enhanced.Fled {
+		caughtBy := enhanced.EnemyAttacks[0].AttackerName
 		s.state.SetLastEvent(&game.EventInfo{
 			Type:     "combat",
-			Subtype:  eventSubtype,
-			Entities: []string{targetID},
+			Subtype:  "flee_failed",
+			Entities: entities,
 		})
+		sb.WriteString(fmt.Sprintf("\nThe %s blocks your retreat. You are still in combat!", caughtBy))
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	if _, err := s.state.Retreat(); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
 	}
+	s.state.ResetTurnsInRoom()
+	s.state.ResetConsecutiveCombat()
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:     "combat",
+		Subtype:  "fled",
+		Entities: entities,
+	})
+
+	newRoom := s.state.GetCurrentRoom()
+	sb.WriteString("\nYou escape back the way you came...\n\n")
+	sb.WriteString(fmt.Sprintf("=== %s ===\n\n", newRoom.Name))
+	sb.WriteString(fmt.Sprintf("%s\n", newRoom.Description))
+	s.writeArrival(&sb, newRoom)
 
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
@@ -930,7 +2292,7 @@ func (s *Server) handleAttack(targetID string) (*ToolResult, error) {
 }
 
 // handleTake picks up an item
-func (s *Server) handleTake(itemID string) (*ToolResult, error) {
+func (s *Session) handleTake(itemID string) (*ToolResult, error) {
 	if errResult := s.requireActiveGameForAction(); errResult != nil {
 		return errResult, nil
 	}
@@ -942,6 +2304,13 @@ func (s *Server) handleTake(itemID string) (*ToolResult, error) {
 		}, nil
 	}
 
+	if err := s.state.CheckTake(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
 	s.beginTurn()
 
 	err := s.state.TakeItem(itemID)
@@ -952,6 +2321,20 @@ func (s *Server) handleTake(itemID string) (*ToolResult, error) {
 		}, nil
 	}
 
+	// Treasure goes straight into the character's gold
+	if item.Type == "treasure" {
+		s.state.SetLastEvent(&game.EventInfo{
+			Type:     "discovery",
+			Subtype:  "treasure_found",
+			Entities: []string{itemID},
+		})
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("You pocket the %s, worth %d gold. (Gold: %d)",
+				item.Name, item.Value, s.state.Character.Gold)}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
 	// Track item taken
 	s.state.RecordItemTaken(itemID)
 	s.state.SetLastEvent(&game.EventInfo{
@@ -967,17 +2350,24 @@ func (s *Server) handleTake(itemID string) (*ToolResult, error) {
 }
 
 // handleUse uses an item from inventory
-func (s *Server) handleUse(itemID string) (*ToolResult, error) {
+func (s *Session) handleUse(itemID, targetID string) (*ToolResult, error) {
 	if errResult := s.requireActiveGameForAction(); errResult != nil {
 		return errResult, nil
 	}
 
+	if err := s.state.CheckUse(itemID, targetID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
 	s.beginTurn()
 
 	// Track item used before it's removed
 	s.state.RecordItemUsed(itemID)
 
-	message, err := s.state.UseItem(itemID)
+	message, err := s.state.UseItem(itemID, targetID)
 	if err != nil {
 		return &ToolResult{
 			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
@@ -985,10 +2375,14 @@ func (s *Server) handleUse(itemID string) (*ToolResult, error) {
 		}, nil
 	}
 
+	entities := []string{itemID}
+	if targetID != "" {
+		entities = append(entities, targetID)
+	}
 	s.state.SetLastEvent(&game.EventInfo{
 		Type:     "interaction",
 		Subtype:  "item_used",
-		Entities: []string{itemID},
+		Entities: entities,
 	})
 
 	return &ToolResult{
@@ -997,8 +2391,58 @@ func (s *Server) handleUse(itemID string) (*ToolResult, error) {
 	}, nil
 }
 
+// handleDrop puts an item from inventory down in the current room
+func (s *Session) handleDrop(itemID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	item, ok := s.state.Items[itemID]
+	if !ok {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "Item not found. Use 'inventory' to see your items."}},
+		}, nil
+	}
+
+	if err := s.state.CheckDrop(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+
+	wasEquipped := item.IsEquipped
+	if err := s.state.DropItem(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.state.RecordItemRemoved(itemID)
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:     "interaction",
+		Subtype:  "item_dropped",
+		Entities: []string{itemID},
+	})
+
+	var sb strings.Builder
+	if wasEquipped {
+		sb.WriteString(fmt.Sprintf("You unequip the %s.\n", item.Name))
+	}
+	sb.WriteString(fmt.Sprintf("You drop the %s. (%d/%d slots used)",
+		item.Name, s.state.InventorySize(), s.state.Character.CarryCapacity()))
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
 // handleInventory shows the character's inventory
-func (s *Server) handleInventory() (*ToolResult, error) {
+func (s *Session) handleInventory() (*ToolResult, error) {
 	if errResult := s.requireInitialized(); errResult != nil {
 		return errResult, nil
 	}
@@ -1007,6 +2451,7 @@ func (s *Server) handleInventory() (*ToolResult, error) {
 
 	var sb strings.Builder
 	sb.WriteString("=== INVENTORY ===\n\n")
+	sb.WriteString(fmt.Sprintf("Slots: %d/%d\n\n", s.state.InventorySize(), s.state.Character.CarryCapacity()))
 
 	if len(items) == 0 {
 		sb.WriteString("Your inventory is empty.\n")
@@ -1016,14 +2461,27 @@ func (s *Server) handleInventory() (*ToolResult, error) {
 			if item.IsEquipped {
 				equippedMarker = " [EQUIPPED]"
 			}
-			sb.WriteString(fmt.Sprintf("- %s%s [ID: %s]\n", item.Name, equippedMarker, item.ID))
+			sb.WriteString(fmt.Sprintf("- %s%s%s [ID: %s]\n", item.Name, rarityTag(item), equippedMarker, item.ID))
 			sb.WriteString(fmt.Sprintf("  %s\n", item.Description))
 			if item.Type == "consumable" && item.Healing > 0 {
 				sb.WriteString(fmt.Sprintf("  (Heals %d HP)\n", item.Healing))
 			}
+			if item.Cures != "" {
+				sb.WriteString(fmt.Sprintf("  (Cures %s)\n", item.Cures))
+			}
+			if item.Effect != nil {
+				if game.IsHarmfulEffect(item.Effect.Type) {
+					sb.WriteString(fmt.Sprintf("  (Thrown: leaves a monster %s)\n", item.Effect.Describe()))
+				} else {
+					sb.WriteString(fmt.Sprintf("  (Leaves you %s)\n", item.Effect.Describe()))
+				}
+			}
 			if item.Type == "weapon" && item.Damage > 0 {
 				sb.WriteString(fmt.Sprintf("  (Damage +%d)\n", item.Damage))
 			}
+			if item.OnHit != nil {
+				sb.WriteString(fmt.Sprintf("  (On hit: %s)\n", item.OnHit.Describe()))
+			}
 			if item.Type == "armor" && item.Armor > 0 {
 				sb.WriteString(fmt.Sprintf("  (Armor +%d)\n", item.Armor))
 			}
@@ -1037,7 +2495,7 @@ func (s *Server) handleInventory() (*ToolResult, error) {
 }
 
 // handleStats shows character stats
-func (s *Server) handleStats() (*ToolResult, error) {
+func (s *Session) handleStats() (*ToolResult, error) {
 	if errResult := s.requireInitialized(); errResult != nil {
 		return errResult, nil
 	}
@@ -1047,9 +2505,27 @@ func (s *Server) handleStats() (*ToolResult, error) {
 	var sb strings.Builder
 	sb.WriteString("=== CHARACTER STATS ===\n\n")
 	sb.WriteString(fmt.Sprintf("Name: %s\n", char.Name))
+	if class, ok := game.Classes[char.Class]; ok {
+		sb.WriteString(fmt.Sprintf("Class: %s\n", class.Name))
+		if class.Passive.Name != "" {
+			sb.WriteString(fmt.Sprintf("Passive: %s (%s)\n", class.Passive.Name, class.Passive.Description))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("Level: %d (XP: %d/%d)\n", char.Level, char.XP, game.XPForLevel(char.Level+1)))
 	sb.WriteString(fmt.Sprintf("HP: %d/%d\n", char.HP, char.MaxHP))
+	if char.MaxMana > 0 {
+		sb.WriteString(fmt.Sprintf("Mana: %d/%d\n", char.Mana, char.MaxMana))
+	}
+	if char.Shield > 0 {
+		sb.WriteString(fmt.Sprintf("Shield: %d\n", char.Shield))
+	}
 	sb.WriteString(fmt.Sprintf("Strength: %d\n", char.Strength))
 	sb.WriteString(fmt.Sprintf("Dexterity: %d\n", char.Dexterity))
+	if char.StatPoints > 0 {
+		sb.WriteString(fmt.Sprintf("Stat points: %d (use 'level_up')\n", char.StatPoints))
+	}
+	sb.WriteString(fmt.Sprintf("Gold: %d\n", char.Gold))
+	sb.WriteString(fmt.Sprintf("Depth: %d/%d\n", s.state.Dungeon.Depth, s.state.FinalDepth))
 	sb.WriteString(fmt.Sprintf("Status: %s\n", func() string {
 		if !char.IsAlive {
 			return "Dead"
@@ -1062,6 +2538,17 @@ func (s *Server) handleStats() (*ToolResult, error) {
 		}
 		return "Healthy"
 	}()))
+	if len(char.Effects) > 0 {
+		sb.WriteString(fmt.Sprintf("Effects: %s\n", game.DescribeEffects(char.Effects)))
+	}
+
+	if known := char.KnownAbilities(); len(known) > 0 {
+		sb.WriteString("\n--- Abilities ---\n")
+		for _, name := range known {
+			ability := game.Abilities[name]
+			sb.WriteString(fmt.Sprintf("%s (%d mana): %s [cast: %s]\n", ability.Name, ability.ManaCost, ability.Description, name))
+		}
+	}
 
 	// Show equipped items
 	sb.WriteString("\n--- Equipment ---\n")
@@ -1085,6 +2572,9 @@ func (s *Server) handleStats() (*ToolResult, error) {
 	if s.state.Victory {
 		sb.WriteString("\n🏆 VICTORIOUS 🏆\n")
 	}
+	if s.state.Score != nil {
+		sb.WriteString(fmt.Sprintf("Final score: %d\n", s.state.Score.Total))
+	}
 
 	// Also show current room summary
 	room := s.state.GetCurrentRoom()
@@ -1094,7 +2584,7 @@ func (s *Server) handleStats() (*ToolResult, error) {
 
 	// Show inventory count
 	inv := s.state.GetInventory()
-	sb.WriteString(fmt.Sprintf("Inventory: %d items\n", len(inv)))
+	sb.WriteString(fmt.Sprintf("Inventory: %d/%d items\n", len(inv), char.CarryCapacity()))
 
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
@@ -1103,12 +2593,12 @@ func (s *Server) handleStats() (*ToolResult, error) {
 }
 
 // handleMap shows the dungeon map
-func (s *Server) handleMap() (*ToolResult, error) {
+func (s *Session) handleMap() (*ToolResult, error) {
 	if errResult := s.requireInitialized(); errResult != nil {
 		return errResult, nil
 	}
 
-	mapStr := s.state.RenderMap(generator.GridSize)
+	mapStr := s.state.RenderMap()
 
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: mapStr}},
@@ -1117,7 +2607,7 @@ func (s *Server) handleMap() (*ToolResult, error) {
 }
 
 // handleEquip equips a weapon or armor
-func (s *Server) handleEquip(itemID string) (*ToolResult, error) {
+func (s *Session) handleEquip(itemID string) (*ToolResult, error) {
 	if errResult := s.requireActiveGameForAction(); errResult != nil {
 		return errResult, nil
 	}
@@ -1175,6 +2665,196 @@ func (s *Server) handleEquip(itemID string) (*ToolResult, error) {
 		}, nil
 	}
 
+	s.acceptAction()
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleUnequip takes off an equipped weapon or armor
+func (s *Session) handleUnequip(itemID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	item, ok := s.state.Items[itemID]
+	if !ok {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "Item not found. Use 'inventory' to see your items."}},
+		}, nil
+	}
+
+	if err := s.state.UnequipItem(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.acceptAction()
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: fmt.Sprintf("You unequip the %s.", item.Name)}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// itemStats summarises what an item does, e.g. "Damage +5, On hit: ..."
+func itemStats(item *game.Item) string {
+	stats := make([]string, 0)
+	if item.Damage > 0 {
+		stats = append(stats, fmt.Sprintf("Damage +%d", item.Damage))
+	}
+	if item.Armor > 0 {
+		stats = append(stats, fmt.Sprintf("Armor +%d", item.Armor))
+	}
+	if item.Healing > 0 {
+		stats = append(stats, fmt.Sprintf("Heals %d HP", item.Healing))
+	}
+	if item.Cures != "" {
+		stats = append(stats, fmt.Sprintf("Cures %s", item.Cures))
+	}
+	if item.Effect != nil {
+		stats = append(stats, item.Effect.Describe())
+	}
+	if item.OnHit != nil {
+		stats = append(stats, "On hit: "+item.OnHit.Describe())
+	}
+	return strings.Join(stats, ", ")
+}
+
+// handleShop lists the wares of the merchant in the current room and what
+// they would pay for the character's items
+func (s *Session) handleShop() (*ToolResult, error) {
+	if errResult := s.requireActiveGame(); errResult != nil {
+		return errResult, nil
+	}
+
+	merchant := s.state.GetRoomMerchant(s.state.Character.CurrentRoomID)
+	if merchant == nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: "There is no merchant here."}},
+		}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("=== %s ===\n\n", strings.ToUpper(merchant.Name)))
+	sb.WriteString(fmt.Sprintf("%s\n\n", merchant.Description))
+	sb.WriteString(fmt.Sprintf("Your gold: %d\n\n", s.state.Character.Gold))
+
+	if len(merchant.Stock) == 0 {
+		sb.WriteString("The merchant has nothing left to sell.\n")
+	} else {
+		sb.WriteString("For sale:\n")
+		for _, item := range merchant.Stock {
+			sb.WriteString(fmt.Sprintf("  - %s%s - %d gold [ID: %s]\n", item.Name, rarityTag(item), game.BuyPrice(item), item.ID))
+			if stats := itemStats(item); stats != "" {
+				sb.WriteString(fmt.Sprintf("    (%s)\n", stats))
+			}
+		}
+	}
+
+	offers := make([]string, 0)
+	for _, item := range s.state.GetInventory() {
+		if game.IsTradeable(item) {
+			offers = append(offers, fmt.Sprintf("  - %s - %d gold [ID: %s]\n", item.Name, game.SellPrice(item), item.ID))
+		}
+	}
+	if len(offers) > 0 {
+		sb.WriteString("\nThe merchant would buy:\n")
+		sb.WriteString(strings.Join(offers, ""))
+	}
+
+	if s.state.HasMonstersInRoom(merchant.RoomID) {
+		sb.WriteString("\nThe merchant won't trade while monsters are about.")
+	} else {
+		sb.WriteString("\nUse 'buy' or 'sell' with an item ID to trade.")
+	}
+
+	return &ToolResult{
+		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleBuy buys an item from the merchant in the current room
+func (s *Session) handleBuy(itemID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	if err := s.state.CheckBuy(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+
+	item, price, err := s.state.BuyItem(itemID)
+	if err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.state.RecordItemTaken(itemID)
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:     "interaction",
+		Subtype:  "item_bought",
+		Entities: []string{itemID},
+	})
+
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("You buy the %s for %d gold. (Gold: %d)",
+			item.Name, price, s.state.Character.Gold)}},
+		GameState: s.buildGameStateSnapshot(),
+	}, nil
+}
+
+// handleSell sells an item from inventory to the merchant in the current room
+func (s *Session) handleSell(itemID string) (*ToolResult, error) {
+	if errResult := s.requireActiveGameForAction(); errResult != nil {
+		return errResult, nil
+	}
+
+	if err := s.state.CheckSell(itemID); err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.beginTurn()
+
+	wasEquipped := false
+	if item, ok := s.state.Items[itemID]; ok {
+		wasEquipped = item.IsEquipped
+	}
+
+	item, price, err := s.state.SellItem(itemID)
+	if err != nil {
+		return &ToolResult{
+			Content:   []ContentBlock{{Type: "text", Text: err.Error()}},
+			GameState: s.buildGameStateSnapshot(),
+		}, nil
+	}
+
+	s.state.RecordItemRemoved(itemID)
+	s.state.SetLastEvent(&game.EventInfo{
+		Type:     "interaction",
+		Subtype:  "item_sold",
+		Entities: []string{itemID},
+	})
+
+	var sb strings.Builder
+	if wasEquipped {
+		sb.WriteString(fmt.Sprintf("You unequip the %s.\n", item.Name))
+	}
+	sb.WriteString(fmt.Sprintf("You sell the %s for %d gold. (Gold: %d)", item.Name, price, s.state.Character.Gold))
+
 	return &ToolResult{
 		Content:   []ContentBlock{{Type: "text", Text: sb.String()}},
 		GameState: s.buildGameStateSnapshot(),
@@ -0,0 +1,341 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dungeon-crawler/internal/db"
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// TestCallToolParallel runs read-only, mutating and new_game calls against
+// one session from several goroutines. Run with -race to check the game
+// state's read/write locking.
+func TestCallToolParallel(t *testing.T) {
+	const (
+		sessionID  = "parallel"
+		goroutines = 8
+		calls      = 50
+	)
+
+	s := NewServer(nil)
+	if _, err := s.CallTool(sessionID, "new_game", map[string]interface{}{}); err != nil {
+		t.Fatalf("new_game: %v", err)
+	}
+
+	// Every read-only tool runs, so new ones are covered as they are added
+	readOnly := make([]string, 0, len(readOnlyTools))
+	for name := range readOnlyTools {
+		readOnly = append(readOnly, name)
+	}
+	sort.Strings(readOnly)
+
+	directions := []string{"north", "south", "east", "west"}
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*calls)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				var name string
+				args := map[string]interface{}{}
+				switch n := g + i; {
+				case n%2 == 0:
+					name = readOnly[n/2%len(readOnly)]
+				case n%10 == 9 && i%5 == 0:
+					name = "new_game"
+				case n%4 == 1:
+					name = "look"
+				default:
+					name = "move"
+					args["direction"] = directions[n%len(directions)]
+				}
+
+				result, err := s.CallTool(sessionID, name, args)
+				if err != nil {
+					errs <- fmt.Errorf("%s: %v", name, err)
+					return
+				}
+				// Encoding reads everything the result points to, so the
+				// race detector also sees snapshots that share game state
+				if _, err := json.Marshal(result); err != nil {
+					errs <- fmt.Errorf("%s: encoding result: %v", name, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// newTestGame starts a seeded game on a new server and returns its session
+func newTestGame(t *testing.T, seed int) (*Server, *Session) {
+	t.Helper()
+	return newTestGameAs(t, seed, game.DefaultClass)
+}
+
+// newTestGameAs is newTestGame for a character of the given class
+func newTestGameAs(t *testing.T, seed int, class string) (*Server, *Session) {
+	t.Helper()
+	s := NewServer(nil)
+	args := map[string]interface{}{"seed": float64(seed), "class": class}
+	if _, err := s.CallTool("test", "new_game", args); err != nil {
+		t.Fatalf("new_game: %v", err)
+	}
+	sess, ok := s.sessions.Get("test")
+	if !ok {
+		t.Fatal("new_game did not create the session")
+	}
+	return s, sess
+}
+
+// rejectMove walks into a wall of the character's room, failing the test if
+// every direction has an exit or the move was somehow accepted
+func rejectMove(t *testing.T, s *Server, sess *Session) {
+	t.Helper()
+	exits := sess.state.GetRoomExits(sess.state.Character.CurrentRoomID)
+	for _, direction := range []string{"north", "south", "east", "west"} {
+		if _, ok := exits[direction]; ok {
+			continue
+		}
+		turn := sess.state.TurnNumber
+		if _, err := s.CallTool("test", "move", map[string]interface{}{"direction": direction}); err != nil {
+			t.Fatalf("move %s: %v", direction, err)
+		}
+		if sess.state.TurnNumber != turn {
+			t.Fatalf("move %s into a wall advanced the turn from %d to %d", direction, turn, sess.state.TurnNumber)
+		}
+		return
+	}
+	t.Fatal("the starting room has an exit in every direction")
+}
+
+func TestRejectedActionSkipsEffects(t *testing.T) {
+	s, sess := newTestGame(t, 42)
+	char := sess.state.Character
+	char.ApplyEffect(game.StatusEffect{Type: game.EffectPoison, Potency: 1, Duration: 3})
+	hp := char.HP
+
+	rejectMove(t, s, sess)
+	if char.HP != hp || char.Effects[0].Duration != 3 {
+		t.Errorf("rejected move ticked effects: HP %d -> %d, poison duration %d", hp, char.HP, char.Effects[0].Duration)
+	}
+
+	if _, err := s.CallTool("test", "look", nil); err != nil {
+		t.Fatalf("look: %v", err)
+	}
+	if char.HP != hp-1 || char.Effects[0].Duration != 2 {
+		t.Errorf("look did not tick effects: HP %d -> %d, poison duration %d", hp, char.HP, char.Effects[0].Duration)
+	}
+}
+
+func TestRejectedActionSkipsWorldTick(t *testing.T) {
+	s, sess := newTestGame(t, 42)
+	roomID := sess.state.Character.CurrentRoomID
+
+	var elsewhere string
+	for id, item := range sess.state.Items {
+		if item.RoomID != nil && *item.RoomID != roomID {
+			elsewhere = id
+			break
+		}
+	}
+	if elsewhere == "" {
+		t.Fatal("no item outside the starting room")
+	}
+	monsterRooms := make(map[string]string)
+	for id, m := range sess.state.Monsters {
+		monsterRooms[id] = m.RoomID
+	}
+	turn, draws := sess.state.TurnNumber, sess.state.Dice.Draws()
+
+	calls := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"take", map[string]interface{}{"item_id": elsewhere}},
+		{"buy", map[string]interface{}{"item_id": elsewhere}},
+	}
+	for _, call := range calls {
+		if _, err := s.CallTool("test", call.name, call.args); err != nil {
+			t.Fatalf("%s: %v", call.name, err)
+		}
+	}
+
+	if sess.state.TurnNumber != turn {
+		t.Errorf("rejected actions advanced the turn from %d to %d", turn, sess.state.TurnNumber)
+	}
+	if got := sess.state.Dice.Draws(); got != draws {
+		t.Errorf("rejected actions drew %d dice", got-draws)
+	}
+	for id, m := range sess.state.Monsters {
+		if m.RoomID != monsterRooms[id] {
+			t.Errorf("monster %s wandered from %s to %s after rejected actions", id, monsterRooms[id], m.RoomID)
+		}
+	}
+}
+
+func TestRejectedActionSkipsManaRegen(t *testing.T) {
+	s, sess := newTestGameAs(t, 42, "mage")
+	char := sess.state.Character
+	if char.MaxMana == 0 {
+		t.Fatal("the mage has no mana")
+	}
+	char.Mana = 0
+
+	rejectMove(t, s, sess)
+	if char.Mana != 0 {
+		t.Errorf("rejected move regenerated mana to %d", char.Mana)
+	}
+
+	if _, err := s.CallTool("test", "look", nil); err != nil {
+		t.Fatalf("look: %v", err)
+	}
+	if char.Mana != game.ManaRegenPerTurn {
+		t.Errorf("look regenerated mana to %d, want %d", char.Mana, game.ManaRegenPerTurn)
+	}
+}
+
+func TestActionLogSkipsRejectedActions(t *testing.T) {
+	s, sess := newTestGame(t, 42)
+
+	rejectMove(t, s, sess)
+	for _, name := range []string{"take", "drop", "equip", "unequip", "buy", "sell"} {
+		if _, err := s.CallTool("test", name, map[string]interface{}{"item_id": "missing"}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if _, err := s.CallTool("test", "look", nil); err != nil {
+		t.Fatalf("look: %v", err)
+	}
+
+	var tools []string
+	for _, action := range sess.state.ActionLog {
+		tools = append(tools, action.Tool)
+	}
+	if len(tools) != 2 || tools[0] != "new_game" || tools[1] != "look" {
+		t.Errorf("action log = %v, want [new_game look]", tools)
+	}
+
+	exported, err := s.CallTool("test", "export_replay", nil)
+	if err != nil {
+		t.Fatalf("export_replay: %v", err)
+	}
+	replayed, err := s.CallTool("other", "replay", map[string]interface{}{"replay": exported.Content[0].Text})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if text := replayed.Content[0].Text; !strings.HasPrefix(text, "Replay verified") {
+		t.Errorf("replay of the exported game: %s", text)
+	}
+}
+
+func TestReplayCreatesSession(t *testing.T) {
+	s, sess := newTestGame(t, 42)
+	if _, err := s.CallTool("test", "look", nil); err != nil {
+		t.Fatalf("look: %v", err)
+	}
+	exported, err := s.CallTool("test", "export_replay", nil)
+	if err != nil {
+		t.Fatalf("export_replay: %v", err)
+	}
+	var original Replay
+	if err := json.Unmarshal([]byte(exported.Content[0].Text), &original); err != nil {
+		t.Fatalf("decoding replay: %v", err)
+	}
+
+	// Into a named session the server has never seen, and into no session at all
+	for _, sessionID := range []string{"other", ""} {
+		replayed, err := s.CallTool(sessionID, "replay", map[string]interface{}{"replay": exported.Content[0].Text})
+		if err != nil {
+			t.Fatalf("replay into %q: %v", sessionID, err)
+		}
+		if replayed.SessionID == "" || (sessionID != "" && replayed.SessionID != sessionID) {
+			t.Errorf("replay into %q returned session %q", sessionID, replayed.SessionID)
+			continue
+		}
+		copied, ok := s.sessions.Get(replayed.SessionID)
+		if !ok {
+			t.Errorf("replay into %q did not register session %s", sessionID, replayed.SessionID)
+			continue
+		}
+		if copied == sess {
+			t.Fatalf("replay into %q ran in the original session", sessionID)
+		}
+
+		if got := snapshotHash(copied.buildGameStateSnapshot(), copied.state.IDPrefix); got != original.StateHash {
+			t.Errorf("replayed session %s state hash %s, want %s", replayed.SessionID, got, original.StateHash)
+		}
+		if copied.state.TurnNumber != sess.state.TurnNumber || len(copied.state.ActionLog) != len(sess.state.ActionLog) {
+			t.Errorf("replayed session %s is on turn %d with %d actions, want turn %d with %d",
+				replayed.SessionID, copied.state.TurnNumber, len(copied.state.ActionLog), sess.state.TurnNumber, len(sess.state.ActionLog))
+		}
+	}
+}
+
+func TestClockSeedFitsJavaScriptNumber(t *testing.T) {
+	now := time.Date(2262, 4, 11, 23, 47, 16, 0, time.UTC) // Close to the largest UnixNano
+	seed := newGameOptions{}.resolveSeed(now)
+	if seed < 0 || seed > 1<<53-1 {
+		t.Errorf("clock seed %d does not fit in a JavaScript number", seed)
+	}
+	if int64(float64(seed)) != seed {
+		t.Errorf("clock seed %d does not survive a round trip through float64", seed)
+	}
+}
+
+func TestEvictIdleSessions(t *testing.T) {
+	store, err := db.New(filepath.Join(t.TempDir(), "games.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer store.Close()
+
+	s := NewServer(store)
+	if _, err := s.CallTool("test", "new_game", map[string]interface{}{"seed": float64(42)}); err != nil {
+		t.Fatalf("new_game: %v", err)
+	}
+	if _, err := s.CallTool("test", "look", nil); err != nil {
+		t.Fatalf("look: %v", err)
+	}
+
+	if n := s.EvictIdleSessions(time.Hour); n != 0 {
+		t.Errorf("evicted %d recently used session(s)", n)
+	}
+	if n := s.EvictIdleSessions(0); n != 1 {
+		t.Fatalf("evicted %d idle session(s), want 1", n)
+	}
+	if s.sessions.Has("test") {
+		t.Fatal("evicted session is still in memory")
+	}
+
+	result, err := s.CallTool("test", "stats", nil)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	sess, ok := s.sessions.Get("test")
+	if !ok {
+		t.Fatalf("evicted session did not resume: %s", result.Content[0].Text)
+	}
+	if sess.state.TurnNumber != 1 {
+		t.Errorf("resumed game is on turn %d, want 1", sess.state.TurnNumber)
+	}
+
+	// Without a store, evicted games could never come back
+	memory, _ := newTestGame(t, 42)
+	if n := memory.EvictIdleSessions(0); n != 0 {
+		t.Errorf("evicted %d session(s) from a server with no store", n)
+	}
+}
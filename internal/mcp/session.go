@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/dungeon-crawler/internal/game"
+)
+
+// SessionHeader is the HTTP header clients use to identify their game session
+const SessionHeader = "X-Session-ID"
+
+// Idle eviction defaults: games unused for SessionIdleTimeout are dropped from
+// memory, checked every SessionSweepInterval
+const (
+	SessionIdleTimeout   = 30 * time.Minute
+	SessionSweepInterval = 5 * time.Minute
+)
+
+// Session holds the game state for a single player
+type Session struct {
+	ID       string
+	state    *game.GameState
+	accepted bool         // The current tool call was carried out, not rejected
+	lastUsed atomic.Int64 // UnixNano of the last tool call, for idle eviction
+}
+
+// newSession creates a session with an empty game state
+func newSession(id string) *Session {
+	return &Session{
+		ID:    id,
+		state: game.NewGameState(),
+	}
+}
+
+// touch records that the session was just used
+func (s *Session) touch() {
+	s.lastUsed.Store(time.Now().UnixNano())
+}
+
+// NewSessionID creates a random session ID
+func NewSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// SessionManager keys game sessions by session ID
+type SessionManager struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewSessionManager creates an empty session manager
+func NewSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Get returns the session with the given ID
+func (m *SessionManager) Get(id string) (*Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sess, ok := m.sessions[id]
+	return sess, ok
+}
+
+// Has returns true if a session with the given ID exists
+func (m *SessionManager) Has(id string) bool {
+	_, ok := m.Get(id)
+	return ok
+}
+
+// GetOrCreate returns the session with the given ID, creating it if needed
+func (m *SessionManager) GetOrCreate(id string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sess, ok := m.sessions[id]; ok {
+		return sess
+	}
+	sess := newSession(id)
+	sess.touch()
+	m.sessions[id] = sess
+	return sess
+}
+
+// Add registers a session. If one with the same ID already exists, the
+// existing session is kept and returned instead.
+func (m *SessionManager) Add(sess *Session) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.sessions[sess.ID]; ok {
+		return existing
+	}
+	sess.touch()
+	m.sessions[sess.ID] = sess
+	return sess
+}
+
+// Delete removes a session
+func (m *SessionManager) Delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// EvictIdle removes the sessions last used before cutoff, returning their IDs
+func (m *SessionManager) EvictIdle(cutoff time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	evicted := make([]string, 0)
+	for id, sess := range m.sessions {
+		if sess.lastUsed.Load() < cutoff.UnixNano() {
+			delete(m.sessions, id)
+			evicted = append(evicted, id)
+		}
+	}
+	return evicted
+}
+
+// CloseSession drops a session's in-memory game (saved games are kept)
+func (s *Server) CloseSession(sessionID string) {
+	s.sessions.Delete(sessionID)
+}
+
+// EvictIdleSessions drops the in-memory games of sessions unused for longer
+// than maxIdle. Every call saves the game, so an evicted session resumes from
+// the store on its next call. Without a store nothing is evicted, since the
+// games would be lost. Returns how many sessions were evicted.
+func (s *Server) EvictIdleSessions(maxIdle time.Duration) int {
+	if s.store == nil {
+		return 0
+	}
+	return len(s.sessions.EvictIdle(time.Now().Add(-maxIdle)))
+}
+
+// StartSessionEviction evicts idle sessions every interval in the background
+// until the returned stop func is called
+func (s *Server) StartSessionEviction(interval, maxIdle time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if n := s.EvictIdleSessions(maxIdle); n > 0 {
+					log.Printf("Evicted %d idle session(s)", n)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxStdioMessageSize bounds a single newline-delimited JSON-RPC message
+const maxStdioMessageSize = 4 * 1024 * 1024
+
+// StdioTransport runs the MCP server over newline-delimited JSON-RPC, as used
+// by desktop MCP hosts that launch servers as subprocesses. Nothing but
+// protocol messages may be written to out; logs belong on stderr.
+type StdioTransport struct {
+	server    *Server
+	in        io.Reader
+	out       io.Writer
+	writeMu   sync.Mutex
+	sessionID string
+}
+
+// NewStdioTransport creates a stdio transport. The whole process shares one
+// session, since a stdio server only ever has a single client. Passing the ID
+// of an earlier session resumes its saved game; an empty ID starts fresh.
+func NewStdioTransport(server *Server, sessionID string, in io.Reader, out io.Writer) *StdioTransport {
+	if sessionID == "" {
+		sessionID = NewSessionID()
+	}
+	return &StdioTransport{
+		server:    server,
+		in:        in,
+		out:       out,
+		sessionID: sessionID,
+	}
+}
+
+// Serve reads messages until in is closed, writing one response line per request
+func (t *StdioTransport) Serve() error {
+	scanner := bufio.NewScanner(t.in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdioMessageSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		response := t.server.HandleJSONRPC(t.sessionID, line)
+		if response == nil {
+			continue
+		}
+		if err := t.writeMessage(response); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	return nil
+}
+
+// writeMessage writes a single message followed by a newline
+func (t *StdioTransport) writeMessage(msg []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if _, err := t.out.Write(append(msg, '\n')); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	return nil
+}